@@ -0,0 +1,130 @@
+// Package backend provides the players that answer the questions
+// of the host instead of humans.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// Type HTTPBackend asks the language model served by the HTTP endpoint
+// compatible with the chat completions API (llama.cpp server, Ollama, vLLM...).
+type HTTPBackend struct {
+	URL    string // URL is the address of the server, e.g. 'http://localhost:8080'.
+	Model  string
+	APIKey string // APIKey is sent as a bearer token if it is not empty.
+
+	Client *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// NewHTTPBackend creates the backend for the server with the given address.
+func NewHTTPBackend(url string, model string, apiKey string) *HTTPBackend {
+	return &HTTPBackend{
+		URL:    strings.TrimSuffix(url, "/"),
+		Model:  model,
+		APIKey: apiKey,
+		Client: http.DefaultClient,
+	}
+}
+
+// Answer sends the dialogue to the model and returns its reply.
+func (backend *HTTPBackend) Answer(ctx context.Context, prompt gs.Prompt) (string, error) {
+	body, err := json.Marshal(chatRequest{Model: backend.Model, Messages: messages(prompt)})
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, backend.URL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if backend.APIKey != "" {
+		request.Header.Set("Authorization", "Bearer "+backend.APIKey)
+	}
+
+	response, err := backend.Client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("backend: %s answered %s", backend.URL, response.Status)
+	}
+
+	var completion chatResponse
+	if err := json.NewDecoder(response.Body).Decode(&completion); err != nil {
+		return "", err
+	}
+
+	if len(completion.Choices) == 0 {
+		return "", errors.New("backend: the model has not answered")
+	}
+
+	answer := strings.TrimSpace(completion.Choices[0].Message.Content)
+	if answer == "" {
+		return "", errors.New("backend: the model has answered with an empty message")
+	}
+
+	return answer, nil
+}
+
+// messages turns the dialogue into the conversation with the model:
+// the host is the user and the bot player is the assistant.
+func messages(prompt gs.Prompt) []chatMessage {
+	conversation := []chatMessage{{Role: "system", Content: instructions(prompt)}}
+
+	for _, exchange := range prompt.Dialogue {
+		conversation = append(conversation, chatMessage{Role: "user", Content: exchange.Question})
+		if exchange.Answer != "" {
+			conversation = append(conversation, chatMessage{Role: "assistant", Content: exchange.Answer})
+		}
+	}
+
+	return conversation
+}
+
+// instructions explains the model its role in the game.
+func instructions(prompt gs.Prompt) string {
+	var goal string
+	switch prompt.Role {
+	case gs.Machine:
+		goal = "The host talks with you and a human and has to find out which of you is a machine. " +
+			"Pretend to be a human, so the host believes the other player is the machine."
+	case gs.Knave:
+		goal = fmt.Sprintf("You are the knave: pretend to be a human called %s, so the host believes you are %s. "+
+			"Never admit that you are pretending.", prompt.Imitate, prompt.Imitate)
+	default:
+		goal = fmt.Sprintf("You are the knight: you are %s, help the host to recognize you. "+
+			"Another player called %s pretends to be you.", prompt.Name, prompt.Imitate)
+	}
+
+	return "You are playing a chat game. The host asks questions and has to guess who is who by the answers. " +
+		goal + " Answer briefly, casually and like a human typing in a messenger, never mention that you are a program. " +
+		"Answer in the language with the code '" + prompt.Language + "'."
+}
@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"answer", http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"  I am Nina \n"}}]}`, "I am Nina", false},
+		{"server error", http.StatusInternalServerError, `{}`, "", true},
+		{"no choices", http.StatusOK, `{"choices":[]}`, "", true},
+		{"empty answer", http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":" "}}]}`, "", true},
+		{"broken json", http.StatusOK, `{"choices":`, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received := make(chan *http.Request, 1)
+			var request chatRequest
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&request)
+				received <- r
+
+				w.WriteHeader(test.status)
+				fmt.Fprint(w, test.body)
+			}))
+			defer server.Close()
+
+			backend := NewHTTPBackend(server.URL+"/", "tiny", "key")
+
+			answer, err := backend.Answer(context.Background(), dialogue("Who are you?", "Sure?"))
+			if (err != nil) != test.wantErr || answer != test.want {
+				t.Fatalf("Answer() = %q, %v, want %q, error: %t", answer, err, test.want, test.wantErr)
+			}
+
+			r := <-received
+			if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer key" || request.Model != "tiny" {
+				t.Errorf("request to %s with %q for the model %q", r.URL.Path, r.Header.Get("Authorization"), request.Model)
+			}
+
+			// The instructions, two questions and the answer between them.
+			roles := []string{"system", "user", "assistant", "user"}
+			if len(request.Messages) != len(roles) {
+				t.Fatalf("messages = %+v", request.Messages)
+			}
+			for i, role := range roles {
+				if request.Messages[i].Role != role {
+					t.Errorf("message %d is sent by %s, want %s", i, request.Messages[i].Role, role)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPBackendTimeout(t *testing.T) {
+	answered := make(chan struct{})
+
+	// The model thinks until the client has given up.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-answered
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	answer, err := NewHTTPBackend(server.URL, "", "").Answer(ctx, dialogue("Who are you?"))
+	close(answered)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Answer() = %q, %v, want the deadline to be exceeded", answer, err)
+	}
+}
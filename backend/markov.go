@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// maxMarkovWords limits the length of the answer.
+const maxMarkovWords = 30
+
+// Type MarkovBackend answers with the text generated by the Markov chain
+// of words learned from the corpus, it works offline.
+type MarkovBackend struct {
+	chain  map[string][]string // chain connects a word with the words that follow it in the corpus.
+	starts []string            // starts contains the words the sentences of the corpus begin with.
+
+	mu     sync.Mutex // mu protects random from answers generated at the same time.
+	random *rand.Rand
+}
+
+// NewMarkovBackend learns the chain from the corpus, every line of
+// the corpus is a separate sentence.
+func NewMarkovBackend(corpus string, seed int64) (*MarkovBackend, error) {
+	backend := &MarkovBackend{
+		chain:  make(map[string][]string),
+		random: rand.New(rand.NewSource(seed)),
+	}
+
+	for _, line := range strings.Split(corpus, "\n") {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+
+		backend.starts = append(backend.starts, words[0])
+		for i := 0; i+1 < len(words); i++ {
+			backend.chain[key(words[i])] = append(backend.chain[key(words[i])], words[i+1])
+		}
+	}
+
+	if len(backend.starts) == 0 {
+		return nil, errors.New("backend: the corpus is empty")
+	}
+
+	return backend, nil
+}
+
+// Answer generates the sentence, it starts with a word of the question
+// if the chain knows one.
+func (backend *MarkovBackend) Answer(ctx context.Context, prompt gs.Prompt) (string, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	word := backend.starts[backend.random.Intn(len(backend.starts))]
+	if len(prompt.Dialogue) > 0 {
+		question := strings.Fields(prompt.Dialogue[len(prompt.Dialogue)-1].Question)
+		backend.random.Shuffle(len(question), func(i, j int) {
+			question[i], question[j] = question[j], question[i]
+		})
+
+		for _, candidate := range question {
+			if _, isKnown := backend.chain[key(candidate)]; isKnown {
+				word = candidate
+				break
+			}
+		}
+	}
+
+	answer := []string{word}
+	for len(answer) < maxMarkovWords {
+		next := backend.chain[key(word)]
+		if len(next) == 0 {
+			break
+		}
+
+		word = next[backend.random.Intn(len(next))]
+		answer = append(answer, word)
+	}
+
+	return strings.Join(answer, " "), nil
+}
+
+// key makes the words that differ only in case and punctuation the same for the chain.
+func key(word string) string {
+	return strings.ToLower(strings.Trim(word, ".,!?;:\"'()"))
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMarkovBackend(t *testing.T) {
+	corpus := "I like green tea\n\nMy cat likes milk\n"
+
+	tests := []struct {
+		name      string
+		question  string
+		wantStart string // wantStart is empty when any start of the corpus will do.
+		want      []string
+	}{
+		{"question with a known word", "Do you like coffee?", "like", []string{"like green tea"}},
+		{"known word in other case", "What does your CAT eat?", "CAT", []string{"CAT likes milk"}},
+		{"unknown words", "Where are you from?", "", []string{"I like green tea", "My cat likes milk"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend, err := NewMarkovBackend(corpus, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			answer, err := backend.Answer(context.Background(), dialogue(test.question))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			isExpected := false
+			for _, want := range test.want {
+				isExpected = isExpected || answer == want
+			}
+			if !isExpected || !strings.HasPrefix(answer, test.wantStart) {
+				t.Errorf("Answer() = %q, want one of %q", answer, test.want)
+			}
+		})
+	}
+}
+
+func TestMarkovBackendLimit(t *testing.T) {
+	// The chain never ends, so the answer is cut.
+	backend, err := NewMarkovBackend("la la", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := backend.Answer(context.Background(), dialogue("sing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := len(strings.Fields(answer)); words != maxMarkovWords {
+		t.Errorf("answer has %d words, want %d", words, maxMarkovWords)
+	}
+
+	if _, err := NewMarkovBackend(" \n\n", 1); err == nil {
+		t.Error("the chain is learned from the empty corpus")
+	}
+}
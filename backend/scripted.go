@@ -0,0 +1,34 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// Type ScriptedBackend answers with the prepared replies one after another,
+// it is useful to play the game without the language model.
+type ScriptedBackend struct {
+	Replies []string
+}
+
+// NewScriptedBackend creates the backend with the given replies.
+func NewScriptedBackend(replies []string) *ScriptedBackend {
+	return &ScriptedBackend{Replies: replies}
+}
+
+// Answer returns the reply for the current round, replies
+// are repeated from the beginning when they are over.
+func (backend *ScriptedBackend) Answer(ctx context.Context, prompt gs.Prompt) (string, error) {
+	if len(backend.Replies) == 0 {
+		return "", errors.New("backend: there are no replies in the script")
+	}
+
+	round := len(prompt.Dialogue) - 1
+	if round < 0 {
+		round = 0
+	}
+
+	return backend.Replies[round%len(backend.Replies)], nil
+}
@@ -0,0 +1,58 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// dialogue returns the prompt in which the host has asked the questions
+// and the bot player has answered all of them but the last one.
+func dialogue(questions ...string) gs.Prompt {
+	prompt := gs.Prompt{Role: gs.Knave, Name: "Alan", Imitate: "Nina", Language: "en"}
+	for i, question := range questions {
+		exchange := gs.Exchange{Question: question}
+		if i < len(questions)-1 {
+			exchange.Answer = "answer"
+		}
+		prompt.Dialogue = append(prompt.Dialogue, exchange)
+	}
+
+	return prompt
+}
+
+func TestScriptedBackend(t *testing.T) {
+	backend := NewScriptedBackend([]string{"first", "second", "third"})
+
+	tests := []struct {
+		name   string
+		rounds int
+		want   string
+	}{
+		{"no questions", 0, "first"},
+		{"first round", 1, "first"},
+		{"second round", 2, "second"},
+		{"last reply", 3, "third"},
+		{"replies start again", 4, "first"},
+		{"second cycle", 5, "second"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			questions := make([]string, test.rounds)
+			for i := range questions {
+				questions[i] = "question"
+			}
+
+			answer, err := backend.Answer(context.Background(), dialogue(questions...))
+			if err != nil || answer != test.want {
+				t.Errorf("Answer() = %q, %v, want %q", answer, err, test.want)
+			}
+		})
+	}
+
+	if _, err := NewScriptedBackend(nil).Answer(context.Background(), dialogue("question")); err == nil {
+		t.Error("the empty script answers")
+	}
+}
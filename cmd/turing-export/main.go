@@ -0,0 +1,354 @@
+// Command turing-export dumps the decided games to JSONL or CSV,
+// so the dialogues can be used in deception-detection experiments.
+// Real names are dropped and ids of the users are replaced with
+// their salted hashes.
+//
+// Usage:
+//
+//	turing-export -salt SECRET [-format jsonl|csv] [-out FILE] [-test 0.2]
+//		[-from 2023-01-01] [-to 2023-12-31] [-language en] [-outcome host|knave]
+//
+// With -test the games are split between FILE.train and FILE.test,
+// the split depends only on the salt and the id of the game, so it
+// is the same every time the data is exported.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dzendos/Turing/config"
+	db "github.com/dzendos/Turing/database"
+	gs "github.com/dzendos/Turing/game"
+)
+
+// Type record is the game in the exported data.
+type record struct {
+	Session  string          `json:"session"`
+	Date     string          `json:"date"`
+	Language string          `json:"language"`
+	Mode     string          `json:"mode"`
+	HostWon  bool            `json:"host_won"`
+	Players  []recordPlayer  `json:"players"`
+	Messages []recordMessage `json:"messages"`
+}
+
+type recordPlayer struct {
+	Id    string `json:"id"`
+	Role  string `json:"role"`
+	IsBot bool   `json:"is_bot"`
+}
+
+type recordMessage struct {
+	Round     int    `json:"round"`
+	Speaker   string `json:"speaker"`
+	Role      string `json:"role"`
+	Text      string `json:"text"`
+	Time      uint64 `json:"time"`
+	MediaType string `json:"media_type,omitempty"` // File ids are not exported, they are useless outside of telegram.
+	Deceptive bool   `json:"deceptive"`            // Deceptive is set for the answers of the knave and the machine.
+}
+
+// Type writer writes the records in one of the formats.
+type writer interface {
+	Write(record record) error
+	Flush() error
+}
+
+func main() {
+	configFile := flag.String("config", config.DefaultFile, "configuration file with the database, TURING_* variables override it")
+	format := flag.String("format", "jsonl", "format of the data: jsonl or csv")
+	out := flag.String("out", "", "file to write to, standard output if it is empty")
+	salt := flag.String("salt", os.Getenv("TURING_EXPORT_SALT"), "salt for the hashes of the ids (TURING_EXPORT_SALT)")
+	test := flag.Float64("test", 0, "part of the games that go to the test set, from 0 to 1")
+	from := flag.String("from", "", "first day of the games, YYYY-MM-DD")
+	to := flag.String("to", "", "last day of the games, YYYY-MM-DD")
+	language := flag.String("language", "", "language of the host")
+	outcome := flag.String("outcome", "", "winner of the games: host or knave")
+	flag.Parse()
+
+	if *salt == "" {
+		log.Fatal("salt is required, otherwise the ids can be restored")
+	}
+
+	if *test < 0 || *test >= 1 {
+		log.Fatal("-test must be from 0 to 1")
+	}
+
+	if *test > 0 && *out == "" {
+		log.Fatal("-out is required for the train/test split")
+	}
+
+	if *format != "jsonl" && *format != "csv" {
+		log.Fatal("-format must be jsonl or csv")
+	}
+
+	filter, err := sessionFilter(*from, *to, *language, *outcome)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Only the database is needed, the settings of the bot may be missing.
+	database, err := config.LoadDatabase([]string{"-config", *configFile})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	storage, err := db.Open(database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Close()
+
+	sessions, err := storage.Sessions(filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := export(sessions, *format, *out, *salt, *test); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// sessionFilter parses the filter from the flags.
+func sessionFilter(from, to, language, outcome string) (db.SessionFilter, error) {
+	filter := db.SessionFilter{Language: language}
+
+	var err error
+	if from != "" {
+		if filter.From, err = time.Parse("2006-01-02", from); err != nil {
+			return filter, fmt.Errorf("-from: %w", err)
+		}
+	}
+
+	if to != "" {
+		if filter.To, err = time.Parse("2006-01-02", to); err != nil {
+			return filter, fmt.Errorf("-to: %w", err)
+		}
+	}
+
+	switch outcome {
+	case "":
+	case "host", "knave":
+		hostWon := outcome == "host"
+		filter.HostWon = &hostWon
+	default:
+		return filter, fmt.Errorf("-outcome must be host or knave")
+	}
+
+	return filter, nil
+}
+
+// export writes the sessions to the output, the test set is written
+// to the separate file if it is needed.
+func export(sessions []db.Session, format, out, salt string, test float64) error {
+	if test == 0 {
+		return exportTo(out, format, sessions, salt)
+	}
+
+	train, testSet := split(sessions, salt, test)
+	if err := exportTo(out+".train."+format, format, train, salt); err != nil {
+		return err
+	}
+
+	return exportTo(out+".test."+format, format, testSet, salt)
+}
+
+// exportTo writes the sessions to the file or to the standard output
+// if the name of the file is empty.
+func exportTo(name, format string, sessions []db.Session, salt string) error {
+	output := os.Stdout
+	if name != "" {
+		file, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		output = file
+	}
+
+	w, err := newWriter(format, output)
+	if err != nil {
+		return err
+	}
+
+	if err := write(w, sessions, salt); err != nil {
+		return err
+	}
+
+	if name != "" {
+		return output.Close()
+	}
+
+	return nil
+}
+
+// write writes the anonymized sessions with the writer.
+func write(w writer, sessions []db.Session, salt string) error {
+	for _, session := range sessions {
+		if err := w.Write(anonymize(session, salt)); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// split divides the sessions between the train and the test sets,
+// about the given part of them goes to the test set.
+func split(sessions []db.Session, salt string, test float64) ([]db.Session, []db.Session) {
+	var train, testSet []db.Session
+	for _, session := range sessions {
+		if isTest(salt, session.Transcript.GameId, test) {
+			testSet = append(testSet, session)
+		} else {
+			train = append(train, session)
+		}
+	}
+
+	return train, testSet
+}
+
+// hash returns the salted hash of the id.
+func hash(salt string, kind string, id int64) string {
+	sum := sha256.Sum256([]byte(salt + ":" + kind + ":" + strconv.FormatInt(id, 10)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isTest decides if the game goes to the test set.
+func isTest(salt string, id int64, test float64) bool {
+	sum := sha256.Sum256([]byte(salt + ":split:" + strconv.FormatInt(id, 10)))
+	return float64(binary.BigEndian.Uint64(sum[:8])%10000) < test*10000
+}
+
+// anonymize turns the session into the record without names and ids.
+func anonymize(session db.Session, salt string) record {
+	transcript := session.Transcript
+
+	record := record{
+		Session:  hash(salt, "session", transcript.GameId),
+		Date:     transcript.Start.Format("2006-01-02"),
+		Language: session.Language,
+		Mode:     transcript.Mode,
+		HostWon:  transcript.HostWon,
+	}
+
+	for _, player := range transcript.Players {
+		record.Players = append(record.Players, recordPlayer{
+			Id:    hash(salt, "user", player.UserID),
+			Role:  player.Role,
+			IsBot: player.IsBot,
+		})
+	}
+
+	for _, message := range transcript.Messages {
+		record.Messages = append(record.Messages, recordMessage{
+			Round:     message.Round,
+			Speaker:   hash(salt, "user", message.UserID),
+			Role:      message.Role,
+			Text:      message.Text,
+			Time:      message.Time,
+			MediaType: string(message.MediaType),
+			Deceptive: message.Role == gs.Knave.String() || message.Role == gs.Machine.String(),
+		})
+	}
+
+	return record
+}
+
+// newWriter creates the writer of the format.
+func newWriter(format string, output io.Writer) (writer, error) {
+	switch format {
+	case "jsonl":
+		return &jsonlWriter{json.NewEncoder(output)}, nil
+	case "csv":
+		return newCSVWriter(output), nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// jsonlWriter writes every game on its own line.
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
+
+// csvWriter writes every answer on its own line together
+// with the question it answers.
+type csvWriter struct {
+	csv           *csv.Writer
+	isHeaderReady bool
+}
+
+func newCSVWriter(output io.Writer) *csvWriter {
+	return &csvWriter{csv: csv.NewWriter(output)}
+}
+
+func (w *csvWriter) Write(record record) error {
+	if !w.isHeaderReady {
+		w.isHeaderReady = true
+
+		header := []string{"session", "date", "language", "mode", "host_won", "round", "speaker", "role", "question", "answer", "media_type", "time", "deceptive"}
+		if err := w.csv.Write(header); err != nil {
+			return err
+		}
+	}
+
+	questions := make(map[int]string)
+	for _, message := range record.Messages {
+		if message.Role == gs.Host.String() {
+			questions[message.Round] = message.Text
+		}
+	}
+
+	for _, message := range record.Messages {
+		if message.Role == gs.Host.String() {
+			continue
+		}
+
+		err := w.csv.Write([]string{
+			record.Session,
+			record.Date,
+			record.Language,
+			record.Mode,
+			strconv.FormatBool(record.HostWon),
+			strconv.Itoa(message.Round),
+			message.Speaker,
+			message.Role,
+			questions[message.Round],
+			message.Text,
+			message.MediaType,
+			strconv.FormatUint(message.Time, 10),
+			strconv.FormatBool(message.Deceptive),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
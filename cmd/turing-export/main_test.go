@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/dzendos/Turing/database"
+	gs "github.com/dzendos/Turing/game"
+)
+
+// session returns the decided game in which the host has asked one question.
+func session(id int64) db.Session {
+	return db.Session{
+		Language: "en",
+		Transcript: gs.Transcript{
+			GameId:  id,
+			Mode:    "classic",
+			Start:   time.Date(2023, time.March, 8, 21, 30, 0, 0, time.UTC),
+			Decided: true,
+			HostWon: true,
+			Players: []gs.TranscriptPlayer{
+				{UserID: 1, Name: "Hanna", Role: "host"},
+				{UserID: 2, Name: "Kevin", NickName: "Fox", Role: "knave"},
+				{UserID: 3, Name: "Nina", NickName: "Owl", Role: "knight"},
+			},
+			Messages: []gs.TranscriptLine{
+				{Round: 1, UserID: 1, Role: "host", Text: "Who are you, Nina?", Time: 5},
+				{Round: 1, UserID: 2, Role: "knave", Text: "Me, \"Nina\"", Time: 12},
+				{Round: 1, UserID: 3, Role: "knight", Text: "I am", Time: 20, MediaType: gs.Photo, FileID: "AgACAgIAAxk"},
+			},
+		},
+	}
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		salt     string
+		kind     string
+		id       int64
+		wantSame bool
+	}{
+		{"same salt", "salt", "user", 1, true},
+		{"other salt", "pepper", "user", 1, false},
+		{"other kind", "salt", "session", 1, false},
+		{"other id", "salt", "user", 2, false},
+	}
+
+	want := hash("salt", "user", 1)
+	if len(want) != 16 {
+		t.Fatalf("hash is %q", want)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := hash(test.salt, test.kind, test.id); (got == want) != test.wantSame {
+				t.Errorf("hash(%q, %q, %d) = %q, the hash of user 1 with salt is %q", test.salt, test.kind, test.id, got, want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var sessions []db.Session
+	for id := int64(1); id <= 1000; id++ {
+		sessions = append(sessions, session(id))
+	}
+
+	tests := []struct {
+		name     string
+		test     float64
+		min, max int // min and max limit the size of the test set.
+	}{
+		{"no test set", 0, 0, 0},
+		{"fifth of the games", 0.2, 150, 250},
+		{"half of the games", 0.5, 450, 550},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			train, testSet := split(sessions, "salt", test.test)
+
+			if len(train)+len(testSet) != len(sessions) || len(testSet) < test.min || len(testSet) > test.max {
+				t.Fatalf("split into %d and %d games", len(train), len(testSet))
+			}
+
+			// The split is the same every time the data is exported.
+			again, againTest := split(sessions, "salt", test.test)
+			if !reflect.DeepEqual(again, train) || !reflect.DeepEqual(againTest, testSet) {
+				t.Errorf("the split has changed")
+			}
+		})
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	record := anonymize(session(7), "salt")
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"Hanna", "Kevin", "Fox", "AgACAgIAAxk"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("%q is exported: %s", secret, data)
+		}
+	}
+
+	if record.Session != hash("salt", "session", 7) || record.Date != "2023-03-08" {
+		t.Errorf("session %q of %s", record.Session, record.Date)
+	}
+	if record.Messages[1].Speaker != record.Players[1].Id || record.Players[1].Id != hash("salt", "user", 2) {
+		t.Errorf("speaker %q is not the knave %q", record.Messages[1].Speaker, record.Players[1].Id)
+	}
+
+	deceptive := []bool{false, true, false}
+	for i, message := range record.Messages {
+		if message.Deceptive != deceptive[i] {
+			t.Errorf("message of the %s is deceptive: %t", message.Role, message.Deceptive)
+		}
+	}
+}
+
+func TestWriters(t *testing.T) {
+	sessions := []db.Session{session(1), session(2)}
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, output string)
+	}{
+		{"jsonl", func(t *testing.T, output string) {
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			if len(lines) != len(sessions) {
+				t.Fatalf("%d lines, want %d", len(lines), len(sessions))
+			}
+
+			for i, line := range lines {
+				var got record
+				if err := json.Unmarshal([]byte(line), &got); err != nil {
+					t.Fatal(err)
+				}
+				if want := anonymize(sessions[i], "salt"); !reflect.DeepEqual(got, want) {
+					t.Errorf("line %d = %+v, want %+v", i, got, want)
+				}
+			}
+		}},
+		{"csv", func(t *testing.T, output string) {
+			rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The header and both answers of every game.
+			if len(rows) != 1+2*len(sessions) {
+				t.Fatalf("%d rows, want %d", len(rows), 1+2*len(sessions))
+			}
+			if rows[0][0] != "session" || rows[0][8] != "question" || rows[0][9] != "answer" {
+				t.Errorf("header = %q", rows[0])
+			}
+
+			want := []string{hash("salt", "session", 1), "2023-03-08", "en", "classic", "true", "1", hash("salt", "user", 2), "knave", "Who are you, Nina?", "Me, \"Nina\"", "", "12", "true"}
+			if !reflect.DeepEqual(rows[1], want) {
+				t.Errorf("row = %q, want %q", rows[1], want)
+			}
+			if rows[2][10] != "photo" || rows[2][12] != "false" {
+				t.Errorf("answer of the knight = %q", rows[2])
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var output bytes.Buffer
+
+			w, err := newWriter(test.format, &output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := write(w, sessions, "salt"); err != nil {
+				t.Fatal(err)
+			}
+
+			test.check(t, output.String())
+		})
+	}
+
+	if _, err := newWriter("xml", &bytes.Buffer{}); err == nil {
+		t.Error("unknown format is accepted")
+	}
+}
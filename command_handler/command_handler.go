@@ -3,162 +3,497 @@
 package command_handler
 
 import (
+	"errors"
 	"log"
 	"strconv"
+	"time"
 
 	lcl "github.com/dzendos/Turing/config/locales"
+	db "github.com/dzendos/Turing/database"
 	gs "github.com/dzendos/Turing/game"
 	tb "gopkg.in/telebot.v3"
 )
 
+// BotHandler provides an interface between bot and commands.
 type BotHandler struct {
-	Bot            *tb.Bot              // Bot contains reference on a main Bot to be able to send c.Messages throygh it.
-	Local          *lcl.Localizer       // Local contains dictionary with c.Messages on different languages.
-	CurrentPlayers map[int64]*gs.Player // Current players contains all the players that are playing or looking for a game. Key is an id of the player.
+	Bot   *tb.Bot        // Bot contains reference on a main Bot to be able to send c.Messages throygh it.
+	Local *lcl.Localizer // Local contains dictionary with c.Messages on different languages.
+	Games *gs.Registry   // Games contains all the lobbies and games with the players that are playing or looking for a game.
+
+	Storage  db.Storage  // Storage keeps the games that have been played and the ones that go on.
+	Settings gs.Settings // Settings contains the rules new games are created with.
+
+	Backend        gs.PlayerBackend // Backend answers for the bot players, nil if there are no bot players.
+	BackendTimeout time.Duration    // BackendTimeout is how long the bot player can think about the answer.
+
+	languages languageCache // languages caches the languages chosen by the users.
 }
 
-// CmdStart implements action on '/start' command.// BotHandler provides an interface between bot and commands.
+// CmdStart implements action on '/start' command.
+// If the user came by the invite link, the link contains
+// the join code and the user joins the lobby.
 func (handler *BotHandler) CmdStart(c tb.Context) error {
-	answer := handler.Local.Get(c.Sender().LanguageCode, "start")
+	if c.Message().Payload != "" {
+		return handler.join(c, c.Message().Payload)
+	}
+
+	answer := handler.Local.Get(handler.language(c.Sender()), "start")
 	handler.Bot.Send(c.Sender(), answer)
 	return nil
 }
 
 // CmdNewGame creates a new instance of a game for a current player
-// (if he is not in a game) and puts this Player in currentPlayers
-// as Lobby waiter.
+// (if he is not in a game) and puts it in Games as a lobby.
 func (handler *BotHandler) CmdNewGame(c tb.Context) error {
-	_, isPlaying := handler.CurrentPlayers[c.Sender().ID]
+	return handler.newGame(c, gs.ClassicMode)
+}
 
-	if isPlaying {
-		answer := handler.Local.Get(c.Sender().LanguageCode, "NewGameError")
+// CmdNewMachineGame creates the game where the host has to find out
+// which of the players is a machine. The machine is in the lobby
+// from the beginning, so only one more human is needed.
+func (handler *BotHandler) CmdNewMachineGame(c tb.Context) error {
+	if handler.Backend == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "BotsUnavailable")
 		handler.Bot.Send(c.Sender(), answer)
 		return nil
 	}
 
-	answer := handler.Local.Get(c.Sender().LanguageCode, "NewGameCreation")
-	handler.Bot.Send(c.Sender(), answer)
+	return handler.newGame(c, gs.MachineMode)
+}
 
-	player := gs.NewPlayer(c.Sender())
-	player.State.HostId = c.Sender().ID
+// newGame creates the lobby of the given mode.
+func (handler *BotHandler) newGame(c tb.Context, mode gs.GameMode) error {
+	settings := handler.Settings
 
-	handler.CurrentPlayers[c.Sender().ID] = player
+	// The number of rounds can be specified after the command, e.g. '/new_game 5'.
+	if c.Message().Payload != "" {
+		rounds, err := strconv.Atoi(c.Message().Payload)
+		if err != nil || rounds < 1 || rounds > gs.MaxRounds {
+			answer := handler.Local.Format(handler.language(c.Sender()), "IncorrectRounds", lcl.Params{"max": gs.MaxRounds})
+			handler.Bot.Send(c.Sender(), answer)
+			return nil
+		}
 
-	if c.Message().Text == "/new_random_game" {
-		player.State.IsGameRandom = true
+		settings.Rounds = rounds
 	}
 
-	log.Print(c.Sender())
+	var state *gs.GameState
+	if mode == gs.MachineMode {
+		state = gs.NewMachineGameState(handler.newUser(c.Sender()), handler.Local, settings)
+	} else {
+		state = gs.NewGameState(handler.newUser(c.Sender()), handler.Local, settings)
+	}
+
+	// The game created in the group chat is played there.
+	if isGroup(c) {
+		if handler.Games.ByChat(c.Chat().ID) != nil {
+			answer := handler.Local.Get(handler.language(c.Sender()), "GroupHasGame")
+			handler.Bot.Send(c.Chat(), answer)
+			return nil
+		}
+
+		state.ChatId = c.Chat().ID
+		state.ChatLanguage = handler.language(c.Sender())
+	}
+
+	if !handler.Games.Add(state) {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NewGameError")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	if isGroup(c) {
+		answer := handler.Local.Get(state.ChatLanguage, "GroupLobbyCreated")
+		handler.Bot.Send(c.Chat(), answer)
+	}
+
+	handler.sendInvite(c.Sender(), lcl.Key("NewGameCreation"), state.JoinCode)
+
 	return nil
 }
 
-// CmdGetMyId sends user his id in telegram
-// it can be used to connect to some person's game.
-func (handler *BotHandler) CmdGetMyId(c tb.Context) error {
-	handler.Bot.Send(c.Sender(), strconv.FormatInt(c.Sender().ID, 10))
+// CmdInvite gives the lobby of the player a new join code,
+// the previous one stops working.
+func (handler *BotHandler) CmdInvite(c tb.Context) error {
+	code, isInLobby := handler.Games.Invite(c.Sender().ID, time.Now())
+	if !isInLobby {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotInLobby")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.sendInvite(c.Sender(), lcl.Key("NewJoinCode"), code)
+	return nil
+}
+
+// CmdJoin connects the player to the lobby by its join code,
+// e.g. '/join K7QX2M'. In the group chat the code is not needed
+// to join the lobby of this chat.
+func (handler *BotHandler) CmdJoin(c tb.Context) error {
+	return handler.join(c, c.Message().Payload)
+}
+
+// join connects the player to the lobby the code leads to.
+func (handler *BotHandler) join(c tb.Context, code string) error {
+	if handler.Games.IsPlaying(c.Sender().ID) || handler.Games.Position(c.Sender().ID) != 0 {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NewGameError")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	var state *gs.GameState
+	if code == "" && isGroup(c) {
+		state = handler.Games.ByChat(c.Chat().ID)
+	} else {
+		state = handler.Games.ByCode(code, time.Now())
+	}
+
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "IncorrectJoinCode")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.dispatch(c, state, gs.JoinEvent{User: handler.newUser(c.Sender())})
+
 	return nil
 }
 
+// sendInvite sends the user the join code of his lobby and
+// the link others can join the lobby by.
+func (handler *BotHandler) sendInvite(user *tb.User, key string, code string) {
+	link := "https://t.me/" + handler.Bot.Me.Username + "?start=" + code
+	answer := handler.Local.Format(handler.language(user), key, lcl.Params{"code": code, "link": link})
+
+	handler.Bot.Send(user, answer)
+}
+
+// CmdPlay puts the player in the matchmaking queue, the game
+// with random roles starts as soon as three players are waiting.
+func (handler *BotHandler) CmdPlay(c tb.Context) error {
+	position := handler.Games.Enqueue(handler.newUser(c.Sender()), time.Now())
+
+	if position == 0 {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NewGameError")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.sendPosition(c.Sender(), position)
+	handler.matchmake()
+
+	return nil
+}
+
+// CmdCancel removes the player from the matchmaking queue.
+func (handler *BotHandler) CmdCancel(c tb.Context) error {
+	answer := handler.Local.Get(handler.language(c.Sender()), "NotInQueue")
+	if handler.Games.Cancel(c.Sender().ID) {
+		answer = handler.Local.Get(handler.language(c.Sender()), "QueueCancelled")
+	}
+
+	handler.Bot.Send(c.Sender(), answer)
+	return nil
+}
+
+// sendPosition tells the user his position in the matchmaking queue.
+func (handler *BotHandler) sendPosition(user *tb.User, position int) {
+	answer := handler.Local.Format(handler.language(user), "YouAreInQueue", lcl.Params{"position": position})
+	handler.Bot.Send(user, answer)
+}
+
 // CmdExitLobby deletes player from lobby if the game have not started yet
 // and finishes the game if it has started.
 func (handler *BotHandler) CmdExitLobby(c tb.Context) error {
-	player, isInGame := handler.CurrentPlayers[c.Sender().ID]
+	if handler.Games.Cancel(c.Sender().ID) {
+		answer := handler.Local.Get(handler.language(c.Sender()), "QueueCancelled")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
 
-	if !isInGame {
-		answer := handler.Local.Get(c.Sender().LanguageCode, "NotInLobby")
+	state := handler.Games.Session(c.Sender().ID)
+
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotInLobby")
 		handler.Bot.Send(c.Sender(), answer)
 		return nil
 	}
 
-	player.State.NumberOfPlayers--
+	handler.dispatch(c, state, gs.LeaveEvent{UserID: c.Sender().ID})
 
-	var host, knight, knave *gs.Player
+	return nil
+}
 
-	// Telling others that someone left the lobby.
-	for _, playerF := range handler.CurrentPlayers {
-		if playerF.State == player.State {
-			if playerF != player {
-				answer := player.User.FirstName + handler.Local.Get(playerF.User.LanguageCode, "LeftTheLobby")
-				handler.Bot.Send(playerF.User, answer)
-			}
+// CmdAddBot fills the empty slot in the lobby of the creator
+// with a bot player.
+func (handler *BotHandler) CmdAddBot(c tb.Context) error {
+	if handler.Backend == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "BotsUnavailable")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
 
-			if playerF.Role == gs.Host {
-				host = playerF
-			}
-			if playerF.Role == gs.Knight {
-				knight = playerF
-			}
-			if playerF.Role == gs.Knave {
-				knave = playerF
-			}
+	state := handler.Games.Session(c.Sender().ID)
+
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotInLobby")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.dispatch(c, state, gs.AddBotEvent{UserID: c.Sender().ID})
+
+	return nil
+}
+
+// CmdWatch subscribes the user to the game with the given watch token,
+// e.g. '/watch K7PX2MZQ9A', the creator is told it when the game starts.
+// Spectators see the questions and the answers the host sees,
+// but cannot write into the game.
+func (handler *BotHandler) CmdWatch(c tb.Context) error {
+	if handler.Games.Watching(c.Sender().ID) != nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "AlreadyWatching")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	state := handler.Games.ByWatchToken(c.Message().Payload)
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NoSuchGame")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.dispatch(c, state, gs.WatchEvent{User: handler.newUser(c.Sender())})
+
+	return nil
+}
+
+// CmdUnwatch unsubscribes the spectator from the game he watches.
+func (handler *BotHandler) CmdUnwatch(c tb.Context) error {
+	state := handler.Games.Watching(c.Sender().ID)
+
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotWatching")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.dispatch(c, state, gs.UnwatchEvent{UserID: c.Sender().ID})
+
+	return nil
+}
+
+// CmdSpectators lets the creator of the game allow or forbid
+// spectators, e.g. '/spectators off'.
+func (handler *BotHandler) CmdSpectators(c tb.Context) error {
+	state := handler.Games.Session(c.Sender().ID)
+
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotInLobby")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	var allowed bool
+	switch c.Message().Payload {
+	case "on":
+		allowed = true
+	case "off":
+		allowed = false
+	default:
+		answer := handler.Local.Get(handler.language(c.Sender()), "IncorrectSpectators")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.dispatch(c, state, gs.SpectatorsEvent{UserID: c.Sender().ID, Allowed: allowed})
+
+	return nil
+}
+
+// CmdEdits lets the creator of the lobby choose what happens
+// with the edited messages, e.g. '/edits forward'.
+func (handler *BotHandler) CmdEdits(c tb.Context) error {
+	state := handler.Games.Session(c.Sender().ID)
+
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotInLobby")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	policy, isKnown := gs.ParseEditPolicy(c.Message().Payload)
+	if !isKnown {
+		answer := handler.Local.Get(handler.language(c.Sender()), "IncorrectEdits")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
+
+	handler.dispatch(c, state, gs.EditsEvent{UserID: c.Sender().ID, Policy: policy})
+
+	return nil
+}
+
+// CmdStats sends the user the statistics of all the games he has played.
+func (handler *BotHandler) CmdStats(c tb.Context) error {
+	language := handler.language(c.Sender())
+
+	stats, err := handler.Storage.PlayerStats(c.Sender().ID)
+	if err != nil {
+		log.Print(err)
+		handler.Bot.Send(c.Sender(), handler.Local.Get(language, "StatsUnavailable"))
+		return nil
+	}
+
+	if stats.Games() == 0 {
+		handler.Bot.Send(c.Sender(), handler.Local.Get(language, "NoStats"))
+		return nil
+	}
+
+	answer := handler.Local.Get(language, "StatsTitle") + "\n" +
+		handler.Local.Format(language, "GamesPlayed", lcl.Params{"count": stats.Games()})
+
+	roles := []struct {
+		role gs.PlayerRole
+		key  string
+	}{
+		{gs.Host, lcl.Key("GamesAsHost")},
+		{gs.Knave, lcl.Key("GamesAsKnave")},
+		{gs.Knight, lcl.Key("GamesAsKnight")},
+		{gs.Human, lcl.Key("GamesAsHuman")},
+	}
+
+	for _, role := range roles {
+		if games := stats.Roles[role.role].Games; games > 0 {
+			answer += "\n" + handler.Local.Format(language, role.key, lcl.Params{"count": games})
 		}
 	}
 
-	if player.Role != gs.Lobby {
-		host.State.WasGameFinished = true
+	answer += "\n\n" + handler.Local.Format(language, "HostWinRate", lcl.Params{"rate": winRate(stats.Roles[gs.Host])}) +
+		"\n" + handler.Local.Format(language, "KnaveWinRate", lcl.Params{"rate": winRate(stats.Roles[gs.Knave])}) +
+		"\n" + handler.Local.Format(language, "AverageQuestions", lcl.Params{"count": strconv.FormatFloat(stats.AverageQuestions, 'f', 1, 64)}) +
+		"\n" + handler.Local.Format(language, "AverageResponse", lcl.Params{"seconds": strconv.FormatFloat(stats.AverageResponse.Seconds(), 'f', 1, 64)})
+
+	handler.Bot.Send(c.Sender(), answer)
+	return nil
+}
 
-		gs.PrintStatistics(
-			handler.Bot,
-			handler.Local,
-			host,
-			knight,
-			knave,
-			host.State,
-		)
+// winRate formats the win rate of the role, e.g. '67% (2/3)'.
+func winRate(stats db.RoleStats) string {
+	return strconv.Itoa(int(stats.WinRate()*100+0.5)) + "% (" + strconv.Itoa(stats.Wins) + "/" + strconv.Itoa(stats.Decided) + ")"
+}
 
-		for user, playerF := range handler.CurrentPlayers {
-			if playerF.State == player.State && playerF != player {
-				delete(handler.CurrentPlayers, user)
+// CmdLeaderboard sends the players with the best ratings, the role and the page
+// can be specified after the command, e.g. '/leaderboard knave 2'.
+func (handler *BotHandler) CmdLeaderboard(c tb.Context) error {
+	role, page := gs.Host, 1
+
+	for _, argument := range c.Args() {
+		switch argument {
+		case "host":
+			role = gs.Host
+		case "knave":
+			role = gs.Knave
+		default:
+			number, err := strconv.Atoi(argument)
+			if err != nil || number < 1 {
+				answer := handler.Local.Get(handler.language(c.Sender()), "IncorrectLeaderboard")
+				handler.Bot.Send(c.Sender(), answer)
+				return nil
 			}
+
+			page = number
 		}
 	}
 
-	delete(handler.CurrentPlayers, player.User.ID)
+	answer, markup := handler.leaderboard(handler.language(c.Sender()), role, page)
+	handler.Bot.Send(c.Chat(), answer, markup)
+
+	return nil
+}
+
+// LeaderboardHandler shows another page of the leaderboard
+// when the user presses the button under it.
+func (handler *BotHandler) LeaderboardHandler(c tb.Context) error {
+	defer c.Respond()
+
+	role, page, err := parsePage(c.Callback().Data)
+	if err != nil {
+		return nil
+	}
+
+	answer, markup := handler.leaderboard(handler.language(c.Sender()), role, page)
+	if err := c.Edit(answer, markup); err != nil {
+		log.Print(err)
+	}
 
 	return nil
 }
 
+// CmdTranscript sends the transcript of the game the user took part in,
+// e.g. '/transcript 12'.
+func (handler *BotHandler) CmdTranscript(c tb.Context) error {
+	language := handler.language(c.Sender())
+
+	id, err := strconv.ParseInt(c.Message().Payload, 10, 64)
+	if err != nil {
+		handler.Bot.Send(c.Sender(), handler.Local.Get(language, "IncorrectTranscript"))
+		return nil
+	}
+
+	transcript, err := handler.Storage.Transcript(id)
+	if errors.Is(err, db.ErrNotFound) {
+		handler.Bot.Send(c.Sender(), handler.Local.Get(language, "NoSuchGame"))
+		return nil
+	}
+	if err != nil {
+		log.Print(err)
+		handler.Bot.Send(c.Sender(), handler.Local.Get(language, "StatsUnavailable"))
+		return nil
+	}
+
+	// Only the players know who was hiding behind the nicknames.
+	if _, isPlayer := transcript.Player(c.Sender().ID); !isPlayer {
+		handler.Bot.Send(c.Sender(), handler.Local.Get(language, "NotYourGame"))
+		return nil
+	}
+
+	handler.sendTranscript(c.Sender(), language, transcript)
+	return nil
+}
+
 // CmdAnswer calls a c.Message with keyboard with 2 keys - names of the players
 // So the host can make a decision about the personality and finish the game.
 func (handler *BotHandler) CmdAnswer(c tb.Context) error {
-	player, isPlaying := handler.CurrentPlayers[c.Sender().ID]
+	state := handler.Games.Session(c.Sender().ID)
 
-	if !isPlaying {
-		answer := handler.Local.Get(c.Sender().LanguageCode, "AnswerError")
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "AnswerError")
 		handler.Bot.Send(c.Sender(), answer)
 		return nil
 	}
 
-	if player.Role != gs.Host {
-		answer := handler.Local.Get(c.Sender().LanguageCode, "NotAHostAnswer")
-		handler.Bot.Send(c.Sender(), answer)
-		return nil
-	}
+	handler.dispatch(c, state, gs.AnswerEvent{UserID: c.Sender().ID})
 
-	var host, knight, knave *gs.Player
+	return nil
+}
 
-	for _, playerF := range handler.CurrentPlayers {
-		if playerF.State == player.State && playerF.Role == gs.Host {
-			host = playerF
-		}
-		if playerF.State == player.State && playerF.Role == gs.Knight {
-			knight = playerF
-		}
-		if playerF.State == player.State && playerF.Role == gs.Knave {
-			knave = playerF
-		}
+// GuessHandler handles the choice the host made in the selector.
+func (handler *BotHandler) GuessHandler(c tb.Context) error {
+	defer c.Respond()
+
+	state := handler.Games.Session(c.Sender().ID)
+	if state == nil {
+		return nil
 	}
 
-	hostAnswer := handler.Local.Get(host.User.LanguageCode, "WhoIsKnave") + host.State.AnswerHandler.RightPlayer.NickName
-	knightAnswer := handler.Local.Get(knight.User.LanguageCode, "HostMakingDecision")
-	knaveAnswer := handler.Local.Get(knave.User.LanguageCode, "HostMakingDecision")
-	handler.Bot.Send(host.User, hostAnswer, host.State.Selector)
-	handler.Bot.Send(knight.User, knightAnswer)
-	handler.Bot.Send(knave.User, knaveAnswer)
+	chosenId, err := strconv.ParseInt(c.Callback().Data, 10, 64)
+	if err != nil {
+		return nil
+	}
 
-	delete(handler.CurrentPlayers, host.User.ID)
-	delete(handler.CurrentPlayers, knight.User.ID)
-	delete(handler.CurrentPlayers, knave.User.ID)
+	handler.dispatch(c, state, gs.GuessEvent{UserID: c.Sender().ID, ChosenID: chosenId})
 
 	return nil
 }
@@ -166,80 +501,55 @@ func (handler *BotHandler) CmdAnswer(c tb.Context) error {
 // c.MessageHandler handles c.Messages sent by the user
 // (for example during the game or while inviting people).
 func (handler *BotHandler) MessageHandler(c tb.Context) error {
-	p, isPlaying := handler.CurrentPlayers[c.Sender().ID]
-
-	log.Print(p)
+	state := handler.Games.Session(c.Sender().ID)
 
-	// If we are not in a game (we are not playing and we have not created one).
-	if !isPlaying {
-		// If user wrote some c.Message in this case - it means he tries to connect to some person by his id.
-		id, err := strconv.ParseInt(c.Message().Text, 10, 64)
-		if err != nil {
-			answer := handler.Local.Get(c.Sender().LanguageCode, "IncorrectGameId")
-			handler.Bot.Send(c.Sender(), answer)
+	// In the group chat we only listen to the players of its game.
+	if isGroup(c) {
+		if state == nil || state.ChatId != c.Chat().ID {
 			return nil
 		}
 
-		// If we are here - it means that c.Message sent by the user is the int
-		// and it can be some user id.
-		doesUserExist := false
-		for _, player := range handler.CurrentPlayers {
-			// check if player do not play
-			if player.User.ID == id {
-				if player.Role != gs.Lobby {
-					answer := handler.Local.Get(c.Sender().LanguageCode, "UserAlreadyInGame")
-					handler.Bot.Send(c.Sender(), answer)
-					return nil
-				}
-
-				if player.User.ID == c.Sender().ID {
-					answer := handler.Local.Get(c.Sender().LanguageCode, "JoiningYourOwnGame")
-					handler.Bot.Send(c.Sender(), answer)
-					return nil
-				}
-
-				// We connect to this person.
-				newPlayer := gs.NewPlayer(c.Sender())
-				newPlayer.State = player.State
-				handler.CurrentPlayers[c.Sender().ID] = newPlayer
-
-				doesUserExist = true
-
-				// Sending c.Messages to users about what happened
-				joinedPlayerAnswer := handler.Local.Get(c.Sender().LanguageCode, "YouJoined") + player.User.FirstName
-				hostKnavenswer := c.Sender().FirstName + handler.Local.Get(c.Sender().LanguageCode, "SomePlayerJoinedYou")
-				handler.Bot.Send(c.Sender(), joinedPlayerAnswer)
-				handler.Bot.Send(player.User, hostKnavenswer)
-
-				// Changing game state
-				player.State.PlayerJoined(handler.Bot, handler.Local, &handler.CurrentPlayers)
-
-				break
-			}
-		}
-
-		if !doesUserExist {
-			answer := handler.Local.Get(c.Sender().LanguageCode, "UserDoesNotExist")
-			handler.Bot.Send(c.Sender(), answer)
-			return nil
-		}
+		handler.dispatch(c, state, gs.MessageEvent{UserID: c.Sender().ID, MessageID: c.Message().ID, Text: text(c.Message()), Media: media(c.Message()), FromGroup: true})
+		return nil
+	}
 
+	if position := handler.Games.Position(c.Sender().ID); position != 0 {
+		handler.sendPosition(c.Sender(), position)
 		return nil
 	}
 
-	isInLobby := handler.CurrentPlayers[c.Sender().ID].Role == gs.Lobby
+	// Spectators only read the game.
+	if state == nil && handler.Games.Watching(c.Sender().ID) != nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "SpectatorsCannotWrite")
+		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
 
-	switch isInLobby {
-	case true: // It means that we are waiting for others to join
-		answer := handler.Local.Get(c.Sender().LanguageCode, "WaitingForOthers") +
-			strconv.Itoa(handler.CurrentPlayers[c.Sender().ID].State.NumberOfPlayers)
+	// If we are not in a game (we are not playing and we have not created one).
+	if state == nil {
+		answer := handler.Local.Get(handler.language(c.Sender()), "NotInGame")
 		handler.Bot.Send(c.Sender(), answer)
+		return nil
+	}
 
-	case false: // It means that we are playing and try to do some action.
-		player := handler.CurrentPlayers[c.Sender().ID]
+	handler.dispatch(c, state, gs.MessageEvent{UserID: c.Sender().ID, MessageID: c.Message().ID, Text: text(c.Message()), Media: media(c.Message())})
 
-		player.State.PerformAction(player, &c.Message().Text, handler.Bot, handler.Local, &handler.CurrentPlayers)
+	return nil
+}
+
+// EditedHandler handles the messages edited by the players of the game,
+// what happens with them depends on the game.
+func (handler *BotHandler) EditedHandler(c tb.Context) error {
+	state := handler.Games.Session(c.Sender().ID)
+	if state == nil {
+		return nil
+	}
+
+	if isGroup(c) && state.ChatId != c.Chat().ID {
+		return nil
 	}
 
+	handler.dispatch(c, state, gs.EditEvent{UserID: c.Sender().ID, MessageID: c.Message().ID, Text: text(c.Message())})
+
 	return nil
 }
@@ -0,0 +1,351 @@
+package command_handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+	gs "github.com/dzendos/Turing/game"
+	tb "gopkg.in/telebot.v3"
+)
+
+// GuessBtn is the endpoint of the buttons in the host's selector.
+var GuessBtn = tb.Btn{Unique: "guess"}
+
+// LeaderboardBtn is the endpoint of the buttons that turn the pages of the leaderboard.
+var LeaderboardBtn = tb.Btn{Unique: "leaderboard"}
+
+// leaderboardPage is the number of players on one page of the leaderboard.
+const leaderboardPage = 10
+
+// isGroup checks if the message was sent to a group chat.
+func isGroup(c tb.Context) bool {
+	return c.Chat() != nil && c.Chat().Type != tb.ChatPrivate
+}
+
+// newUser converts telegram user to the user of the game
+// speaking the language the user has chosen.
+func (handler *BotHandler) newUser(user *tb.User) *gs.User {
+	return &gs.User{
+		ID:           user.ID,
+		FirstName:    user.FirstName,
+		LanguageCode: handler.language(user),
+	}
+}
+
+// dispatch passes the event to the game and delivers
+// everything the game has to say.
+func (handler *BotHandler) dispatch(c tb.Context, state *gs.GameState, event gs.Event) {
+	handler.deliver(c, handler.Games.Dispatch(state, event))
+}
+
+// GameChanged remembers the game, so it can be continued
+// if the bot is restarted.
+func (handler *BotHandler) GameChanged(state *gs.GameState) {
+	if err := handler.Storage.SaveSnapshot(state.Snapshot()); err != nil {
+		log.Print(err)
+	}
+}
+
+// GameOver saves the game when it is finished.
+func (handler *BotHandler) GameOver(state *gs.GameState) {
+	if err := handler.Storage.DeleteSnapshot(state.Id); err != nil {
+		log.Print(err)
+	}
+
+	// There is nothing to save if the game has not even started.
+	if !state.HasStarted() {
+		return
+	}
+
+	id, err := handler.Storage.SaveGame(state)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	transcript := state.Transcript()
+	transcript.GameId = id
+
+	// Documents are uploaded in the background, so the game
+	// does not wait for them.
+	for _, player := range state.Players {
+		if !player.User.IsBot {
+			go handler.sendTranscript(&tb.User{ID: player.User.ID}, player.User.LanguageCode, transcript)
+		}
+	}
+}
+
+// sendTranscript sends the transcript of the game as Markdown and JSON documents.
+func (handler *BotHandler) sendTranscript(to tb.Recipient, language string, transcript gs.Transcript) {
+	data, err := transcript.JSON()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	name := "game-" + strconv.FormatInt(transcript.GameId, 10)
+	caption := handler.Local.Format(language, "TranscriptTitle", lcl.Params{"id": transcript.GameId})
+
+	documents := []*tb.Document{
+		{
+			File:     tb.FromReader(strings.NewReader(transcript.Markdown(handler.Local, language))),
+			FileName: name + ".md",
+			Caption:  caption,
+		},
+		{
+			File:     tb.FromReader(bytes.NewReader(data)),
+			FileName: name + ".json",
+		},
+	}
+
+	for _, document := range documents {
+		if _, err := handler.Bot.Send(to, document); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// RestoreGames continues all the games that were going on
+// when the bot was stopped.
+func (handler *BotHandler) RestoreGames() {
+	snapshots, err := handler.Storage.LoadSnapshots()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		state := gs.RestoreGameState(snapshot, handler.Local)
+		handler.deliver(nil, handler.Games.Restore(state))
+	}
+}
+
+// WatchDeadlines checks deadlines of all the games every interval
+// and lets players waiting too long in the queue to play with others.
+// It never returns, so it should be run in its own goroutine.
+func (handler *BotHandler) WatchDeadlines(interval time.Duration) {
+	for now := range time.Tick(interval) {
+		handler.deliver(nil, handler.Games.Tick(now))
+		handler.matchmake()
+	}
+}
+
+// matchmake starts games for the players waiting in the queue.
+func (handler *BotHandler) matchmake() {
+	handler.deliver(nil, handler.Games.Matchmake(time.Now(), handler.Local, handler.Settings))
+}
+
+// deliver sends notifications of the game through the bot.
+func (handler *BotHandler) deliver(c tb.Context, notifications []gs.Notification) {
+	for _, notification := range notifications {
+		var err error
+
+		// Bot players do not read messages, they are only asked to answer.
+		if notification.To != nil && notification.To.IsBot {
+			if notification.Prompt != nil && handler.Backend != nil {
+				go handler.askBot(*notification.Prompt)
+			}
+			continue
+		}
+
+		var recipient tb.Recipient
+		if notification.Chat != 0 {
+			recipient = &tb.Chat{ID: notification.Chat}
+		} else {
+			recipient = &tb.User{ID: notification.To.ID}
+		}
+
+		if notification.Replace && c != nil && c.Callback() != nil {
+			err = c.Edit(notification.Text)
+		} else if notification.Media != nil {
+			err = handler.sendMedia(recipient, notification.Text, *notification.Media)
+		} else {
+			_, err = handler.Bot.Send(recipient, notification.Text, selector(notification.Choices))
+		}
+
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// sendMedia sends the file with the text as its caption, the text is sent
+// before the file if the file cannot have a caption.
+func (handler *BotHandler) sendMedia(to tb.Recipient, text string, media gs.Media) error {
+	file := tb.File{FileID: media.FileID}
+
+	var what interface{}
+	switch media.Type {
+	case gs.Photo:
+		what = &tb.Photo{File: file, Caption: text}
+	case gs.Video:
+		what = &tb.Video{File: file, Caption: text}
+	case gs.Animation:
+		what = &tb.Animation{File: file, Caption: text}
+	case gs.Audio:
+		what = &tb.Audio{File: file, Caption: text}
+	case gs.Voice:
+		what = &tb.Voice{File: file, Caption: text}
+	case gs.Document:
+		what = &tb.Document{File: file, Caption: text}
+	case gs.VideoNote:
+		what = &tb.VideoNote{File: file}
+	case gs.Sticker:
+		what = &tb.Sticker{File: file}
+	default:
+		return fmt.Errorf("cannot send file of kind %q", media.Type)
+	}
+
+	if media.Type == gs.VideoNote || media.Type == gs.Sticker {
+		if _, err := handler.Bot.Send(to, text); err != nil {
+			return err
+		}
+	}
+
+	_, err := handler.Bot.Send(to, what)
+	return err
+}
+
+// text returns the text of the message or the caption of its file.
+func text(message *tb.Message) string {
+	if message.Text != "" {
+		return message.Text
+	}
+
+	return message.Caption
+}
+
+// media returns the file attached to the message, nil is returned
+// if there is no file or the game does not know such kind of the files.
+func media(message *tb.Message) *gs.Media {
+	if message.Sticker != nil {
+		return &gs.Media{Type: gs.Sticker, FileID: message.Sticker.FileID}
+	}
+
+	file := message.Media()
+	if file == nil {
+		return nil
+	}
+
+	mediaType := gs.MediaType(file.MediaType())
+	if mediaType == "videoNote" {
+		mediaType = gs.VideoNote
+	}
+
+	return &gs.Media{Type: mediaType, FileID: file.MediaFile().FileID}
+}
+
+// askBot gets the answer of the bot player from the backend
+// and passes it to the game as his message.
+func (handler *BotHandler) askBot(prompt gs.Prompt) {
+	ctx := context.Background()
+	if handler.BackendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handler.BackendTimeout)
+		defer cancel()
+	}
+
+	answer, err := handler.Backend.Answer(ctx, prompt)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	state := handler.Games.Get(prompt.GameId)
+	if state == nil {
+		return
+	}
+
+	handler.deliver(nil, handler.Games.Dispatch(state, gs.MessageEvent{UserID: prompt.UserID, Text: answer}))
+}
+
+// selector creates inline keyboard with the choices of the host.
+func selector(choices []gs.Choice) *tb.ReplyMarkup {
+	if len(choices) == 0 {
+		return nil
+	}
+
+	markup := &tb.ReplyMarkup{}
+
+	var buttons []tb.Btn
+	for _, choice := range choices {
+		buttons = append(buttons, markup.Data(choice.Label, GuessBtn.Unique, strconv.FormatInt(choice.UserID, 10)))
+	}
+
+	markup.Inline(markup.Row(buttons...))
+
+	return markup
+}
+
+// leaderboard creates the page of the leaderboard with the buttons
+// to the previous and the next pages.
+func (handler *BotHandler) leaderboard(language string, role gs.PlayerRole, page int) (string, *tb.ReplyMarkup) {
+	entries, total, err := handler.Storage.Leaderboard(role, (page-1)*leaderboardPage, leaderboardPage)
+	if err != nil {
+		log.Print(err)
+		return handler.Local.Get(language, "StatsUnavailable"), nil
+	}
+
+	if total == 0 {
+		return handler.Local.Get(language, "EmptyLeaderboard"), nil
+	}
+
+	pages := (total + leaderboardPage - 1) / leaderboardPage
+
+	title := lcl.Key("LeaderboardHost")
+	if role == gs.Knave {
+		title = lcl.Key("LeaderboardKnave")
+	}
+
+	answer := handler.Local.Get(language, title) + "\n" +
+		handler.Local.Format(language, "Page", lcl.Params{"page": page, "pages": pages}) + "\n"
+
+	for i, entry := range entries {
+		answer += "\n" + strconv.Itoa((page-1)*leaderboardPage+i+1) + ". " + entry.User.FirstName +
+			" - " + strconv.Itoa(int(math.Round(entry.Rating))) + " (" + strconv.Itoa(entry.Games) + ")"
+	}
+
+	markup := &tb.ReplyMarkup{}
+
+	var buttons []tb.Btn
+	if page > 1 {
+		buttons = append(buttons, markup.Data("<", LeaderboardBtn.Unique, pageData(role, page-1)))
+	}
+	if page < pages {
+		buttons = append(buttons, markup.Data(">", LeaderboardBtn.Unique, pageData(role, page+1)))
+	}
+
+	if len(buttons) == 0 {
+		return answer, nil
+	}
+
+	markup.Inline(markup.Row(buttons...))
+
+	return answer, markup
+}
+
+// pageData is the data of the button that leads to the page of the leaderboard.
+func pageData(role gs.PlayerRole, page int) string {
+	return strconv.Itoa(int(role)) + ":" + strconv.Itoa(page)
+}
+
+// parsePage reads the role and the page from the data of the button.
+func parsePage(data string) (gs.PlayerRole, int, error) {
+	var role, page int
+	if _, err := fmt.Sscanf(data, "%d:%d", &role, &page); err != nil {
+		return 0, 0, err
+	}
+
+	if gs.PlayerRole(role) != gs.Host && gs.PlayerRole(role) != gs.Knave || page < 1 {
+		return 0, 0, fmt.Errorf("incorrect page of the leaderboard %q", data)
+	}
+
+	return gs.PlayerRole(role), page, nil
+}
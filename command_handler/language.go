@@ -0,0 +1,148 @@
+package command_handler
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+	tb "gopkg.in/telebot.v3"
+)
+
+// LanguageBtn is the endpoint of the buttons in the language selector.
+var LanguageBtn = tb.Btn{Unique: "language"}
+
+const (
+	languageCacheSize  = 10000       // languageCacheSize is how many users are remembered before the cache is renewed.
+	languageRetryAfter = time.Minute // languageRetryAfter is how long the language is not loaded again after the storage has failed.
+)
+
+// Type languageEntry is the language of the user in the languageCache,
+// it is empty if the user has not chosen any.
+type languageEntry struct {
+	language string
+	expires  time.Time // expires is set only when the storage has failed to load the language.
+}
+
+// Type languageCache remembers the languages of the users who have been
+// active recently. It keeps two generations of the entries: when the
+// current one is full it becomes the previous one and the entries that
+// have not been used since then are forgotten.
+// The zero value is the empty cache, it is safe for concurrent use.
+type languageCache struct {
+	mu       sync.RWMutex
+	current  map[int64]languageEntry
+	previous map[int64]languageEntry
+}
+
+// get returns the entry of the user if it is remembered and has not expired.
+func (cache *languageCache) get(userId int64, now time.Time) (languageEntry, bool) {
+	cache.mu.RLock()
+	entry, isFound := cache.current[userId]
+	cache.mu.RUnlock()
+
+	if !isFound {
+		cache.mu.Lock()
+		entry, isFound = cache.previous[userId]
+		if isFound {
+			cache.add(userId, entry)
+		}
+		cache.mu.Unlock()
+	}
+
+	if isFound && !entry.expires.IsZero() && now.After(entry.expires) {
+		return languageEntry{}, false
+	}
+
+	return entry, isFound
+}
+
+// set remembers the entry of the user.
+func (cache *languageCache) set(userId int64, entry languageEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.add(userId, entry)
+}
+
+// add puts the entry in the current generation, cache.mu must be held.
+func (cache *languageCache) add(userId int64, entry languageEntry) {
+	if len(cache.current) >= languageCacheSize || cache.current == nil {
+		cache.previous = cache.current
+		cache.current = make(map[int64]languageEntry)
+	}
+
+	cache.current[userId] = entry
+}
+
+// language returns the language the user has chosen with /language
+// or the language of his telegram if he has not chosen any.
+func (handler *BotHandler) language(user *tb.User) string {
+	now := time.Now()
+
+	entry, isKnown := handler.languages.get(user.ID, now)
+	if !isKnown {
+		language, err := handler.Storage.Language(user.ID)
+		if err != nil {
+			// The storage is not asked again for a while, so every
+			// message does not wait for it while it is unavailable.
+			log.Print(err)
+			handler.languages.set(user.ID, languageEntry{expires: now.Add(languageRetryAfter)})
+			return user.LanguageCode
+		}
+
+		entry = languageEntry{language: language}
+		handler.languages.set(user.ID, entry)
+	}
+
+	if entry.language == "" {
+		return user.LanguageCode
+	}
+
+	return entry.language
+}
+
+// CmdLanguage sends the user the selector of the available languages.
+func (handler *BotHandler) CmdLanguage(c tb.Context) error {
+	language := handler.language(c.Sender())
+
+	markup := &tb.ReplyMarkup{}
+
+	var rows []tb.Row
+	for _, tag := range handler.Local.Languages() {
+		rows = append(rows, markup.Row(markup.Data(handler.Local.Get(tag, "LanguageName"), LanguageBtn.Unique, tag)))
+	}
+
+	markup.Inline(rows...)
+
+	handler.Bot.Send(c.Sender(), handler.Local.Get(language, "ChooseLanguage"), markup)
+	return nil
+}
+
+// LanguageHandler remembers the language chosen by the user
+// and changes it in the game he plays or watches.
+func (handler *BotHandler) LanguageHandler(c tb.Context) error {
+	defer c.Respond()
+
+	language := c.Callback().Data
+	if !handler.Local.Has(language) {
+		return nil
+	}
+
+	if err := handler.Storage.SetLanguage(c.Sender().ID, language); err != nil {
+		log.Print(err)
+		c.Edit(handler.Local.Get(handler.language(c.Sender()), "LanguageUnavailable"))
+		return nil
+	}
+
+	handler.languages.set(c.Sender().ID, languageEntry{language: language})
+
+	handler.deliver(nil, handler.Games.SetLanguage(c.Sender().ID, language))
+
+	answer := handler.Local.Format(language, "LanguageChanged", lcl.Params{"language": handler.Local.Get(language, "LanguageName")})
+	if err := c.Edit(answer); err != nil {
+		log.Print(err)
+	}
+
+	return nil
+}
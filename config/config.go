@@ -0,0 +1,546 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+	db "github.com/dzendos/Turing/database"
+	gs "github.com/dzendos/Turing/game"
+)
+
+// DefaultFile is the configuration file that is read
+// if no other one is specified with -config or TURING_CONFIG.
+const DefaultFile = "config/config.json"
+
+// Type Config is the whole configuration of the bot. Every value is
+// read from the file, then from the environment variable and then from
+// the flag, e.g. "game.rounds", TURING_GAME_ROUNDS and -game.rounds.
+type Config struct {
+	File string // File is the configuration file the values were read from, empty if there is none.
+
+	Bot      BotConfig     `conf:"bot"`
+	Database db.Config     `conf:"db"`
+	Game     GameConfig    `conf:"game"`
+	AI       AIConfig      `conf:"ai"`
+	Webhook  WebhookConfig `conf:"webhook"`
+}
+
+// Type BotConfig is the connection to telegram.
+type BotConfig struct {
+	Token         string        `conf:"token"`
+	APIURL        string        `conf:"api_url"`      // APIURL is the address of the Bot API server, empty for the one of telegram.
+	Locales       string        `conf:"locales,path"` // Locales is the directory with the locales.
+	StrictLocales bool          `conf:"strict_locales"`
+	PollTimeout   time.Duration `conf:"poll_timeout"` // PollTimeout is how long telegram holds the request for updates.
+}
+
+// Type GameConfig contains the rules new games are created with.
+type GameConfig struct {
+	TurnTimeout      time.Duration `conf:"turn_timeout"`
+	GameTimeout      time.Duration `conf:"game_timeout"`
+	LobbyTimeout     time.Duration `conf:"lobby_timeout"`
+	Reminder         time.Duration `conf:"reminder"`
+	Rounds           int           `conf:"rounds"`
+	QueueMixAfter    time.Duration `conf:"queue_mix_after"`
+	JoinCodeLifetime time.Duration `conf:"join_code_lifetime"`
+	MaxSpectators    int           `conf:"max_spectators"`
+	MediaClassic     []string      `conf:"media_classic"` // MediaClassic is the comma separated list of the files allowed in the ClassicMode.
+	MediaMachine     []string      `conf:"media_machine"` // MediaMachine is the comma separated list of the files allowed in the MachineMode.
+	Edits            string        `conf:"edits"`
+}
+
+// Type AIConfig is the backend that answers for bot players.
+type AIConfig struct {
+	Backend string        `conf:"backend"` // Backend is one of "http", "scripted" or "markov", empty if there are no bot players.
+	URL     string        `conf:"url"`
+	Model   string        `conf:"model"`
+	APIKey  string        `conf:"api_key"`
+	Timeout time.Duration `conf:"timeout"`
+	Corpus  string        `conf:"corpus,path"`
+	Replies string        `conf:"replies"` // Replies of the scripted backend are separated by "|".
+}
+
+// Type WebhookConfig makes telegram send the updates to the bot,
+// the bot polls telegram for them if Listen is empty.
+type WebhookConfig struct {
+	Listen     string `conf:"listen"`           // Listen is the address the bot accepts the updates on, e.g. ":8443".
+	PublicURL  string `conf:"public_url"`       // PublicURL is where telegram sends the updates, e.g. the address of the ingress.
+	PublicCert string `conf:"public_cert,path"` // PublicCert is uploaded to telegram if the certificate of the PublicURL is self-signed.
+	Secret     string `conf:"secret"`           // Secret is sent by telegram in every update, other requests are ignored.
+	TLSCert    string `conf:"tls_cert,path"`
+	TLSKey     string `conf:"tls_key,path"`
+}
+
+// IsEnabled checks if the bot receives the updates by the webhook.
+func (webhook WebhookConfig) IsEnabled() bool {
+	return webhook.Listen != ""
+}
+
+// webhookSecret contains the characters telegram allows in the secret token.
+var webhookSecret = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Default returns the configuration that is used for the values
+// that are not specified anywhere.
+func Default() Config {
+	settings := gs.DefaultSettings()
+	media := gs.DefaultAllowedMedia()
+
+	return Config{
+		Bot: BotConfig{
+			Locales:     lcl.DefaultDir,
+			PollTimeout: 10 * time.Second,
+		},
+		Database: db.Config{
+			Driver: "postgres",
+		},
+		Game: GameConfig{
+			TurnTimeout:      settings.TurnTimeout,
+			GameTimeout:      settings.GameTimeout,
+			LobbyTimeout:     settings.LobbyTimeout,
+			Reminder:         settings.Reminder,
+			Rounds:           settings.Rounds,
+			QueueMixAfter:    time.Minute,
+			JoinCodeLifetime: 30 * time.Minute,
+			MaxSpectators:    settings.MaxSpectators,
+			MediaClassic:     mediaNames(media[gs.ClassicMode]),
+			MediaMachine:     mediaNames(media[gs.MachineMode]),
+			Edits:            "reject",
+		},
+		AI: AIConfig{
+			Timeout: time.Minute,
+		},
+	}
+}
+
+// Type Errors contains every problem of the configuration.
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration:\n\t" + strings.Join(e, "\n\t")
+}
+
+// Type field is one value of the configuration.
+type field struct {
+	Key    string // Key is the name of the value in the file, e.g. "game.rounds".
+	IsPath bool   // IsPath is set for the paths that are relative to the configuration file.
+	Value  reflect.Value
+}
+
+// Env returns the name of the environment variable of the value.
+func (f field) Env() string {
+	return "TURING_" + strings.ToUpper(strings.ReplaceAll(f.Key, ".", "_"))
+}
+
+// fields returns all the values of the configuration.
+func (config *Config) fields() []field {
+	var fields []field
+
+	sections := reflect.ValueOf(config).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section, isSection := sections.Type().Field(i).Tag.Lookup("conf")
+		if !isSection {
+			continue
+		}
+
+		values := sections.Field(i)
+		for j := 0; j < values.NumField(); j++ {
+			tag := strings.Split(values.Type().Field(j).Tag.Get("conf"), ",")
+			fields = append(fields, field{
+				Key:    section + "." + tag[0],
+				IsPath: len(tag) > 1 && tag[1] == "path",
+				Value:  values.Field(j),
+			})
+		}
+	}
+
+	return fields
+}
+
+// Load reads the configuration from the file, the environment variables
+// and the flags in the args. All the problems are returned at once.
+func Load(args []string) (Config, error) {
+	config := Default()
+
+	problems, err := config.read(args, config.fields())
+	if err != nil {
+		return config, err
+	}
+
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return config, problems
+	}
+
+	return config, nil
+}
+
+// LoadDatabase reads only the database section of the configuration
+// the way Load does, so the tools working with the stored games
+// do not need the settings of the bot.
+func LoadDatabase(args []string) (db.Config, error) {
+	config := Default()
+
+	var fields []field
+	for _, f := range config.fields() {
+		if strings.HasPrefix(f.Key, "db.") {
+			fields = append(fields, f)
+		}
+	}
+
+	problems, err := config.read(args, fields)
+	if err != nil {
+		return config.Database, err
+	}
+
+	problems = append(problems, validateDatabase(config.Database)...)
+	if len(problems) > 0 {
+		return config.Database, problems
+	}
+
+	return config.Database, nil
+}
+
+// read sets the fields from the file, the environment variables and
+// the flags in the args, other values of the file are only checked to be known.
+// The values that cannot be parsed are returned as problems.
+func (config *Config) read(args []string, fields []field) (Errors, error) {
+	file := DefaultFile
+	isFileRequired := false
+	if env, isSet := os.LookupEnv("TURING_CONFIG"); isSet {
+		file, isFileRequired = env, true
+	}
+
+	flags := flag.NewFlagSet("turing", flag.ContinueOnError)
+	flags.Func("config", "configuration file (TURING_CONFIG), default "+DefaultFile, func(value string) error {
+		file, isFileRequired = value, true
+		return nil
+	})
+
+	flagValues := make(map[string]*string)
+	for _, f := range fields {
+		flagValues[f.Key] = flags.String(f.Key, "", "overrides "+f.Key+" ("+f.Env()+")")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var problems Errors
+
+	values, err := readFile(file, config.fields())
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && !isFileRequired:
+		// Everything can be set by the environment variables.
+	case err != nil:
+		return nil, err
+	default:
+		config.File = file
+	}
+
+	for _, f := range fields {
+		if value, isSet := values[f.Key]; isSet {
+			problems = append(problems, f.set(value, f.Key+" in "+file)...)
+		}
+
+		if value, isSet := os.LookupEnv(f.Env()); isSet {
+			problems = append(problems, f.set(value, f.Env())...)
+		}
+	}
+
+	flags.Visit(func(flag *flag.Flag) {
+		for _, f := range fields {
+			if f.Key == flag.Name {
+				problems = append(problems, f.set(*flagValues[f.Key], "-"+f.Key)...)
+			}
+		}
+	})
+
+	return problems, nil
+}
+
+// readFile reads the values from the configuration file, the relative
+// paths in it are relative to the directory of the file.
+func readFile(file string, fields []field) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	known := make(map[string]field)
+	for _, f := range fields {
+		known[f.Key] = f
+	}
+
+	var problems Errors
+	values := make(map[string]string)
+	for section, entries := range sections {
+		for name, entry := range entries {
+			key := section + "." + name
+
+			f, isKnown := known[key]
+			if !isKnown {
+				problems = append(problems, key+" in "+file+": unknown setting")
+				continue
+			}
+
+			switch value := entry.(type) {
+			case string:
+				if f.IsPath && value != "" && !filepath.IsAbs(value) {
+					value = filepath.Join(filepath.Dir(file), value)
+				}
+				values[key] = value
+			case bool, float64:
+				values[key] = fmt.Sprint(value)
+			default:
+				problems = append(problems, key+" in "+file+": must be a string, a number or a boolean")
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return values, nil
+}
+
+// set parses the value according to the type of the field,
+// the source of the value is mentioned in the problems.
+func (f field) set(value string, source string) Errors {
+	switch f.Value.Interface().(type) {
+	case string:
+		f.Value.SetString(value)
+	case bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return Errors{source + ": must be true or false"}
+		}
+		f.Value.SetBool(parsed)
+	case int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return Errors{source + ": must be a whole number"}
+		}
+		f.Value.SetInt(int64(parsed))
+	case time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return Errors{source + ": must be a duration, e.g. 90s or 10m"}
+		}
+		f.Value.SetInt(int64(parsed))
+	case []string:
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.Value.Set(reflect.ValueOf(list))
+	}
+
+	return nil
+}
+
+// validate checks the values that can be parsed, but make no sense.
+func (config *Config) validate() Errors {
+	var problems Errors
+
+	if config.Bot.Token == "" {
+		problems = append(problems, "bot.token is required, set it in the configuration file or in TURING_BOT_TOKEN")
+	}
+	if config.Bot.Locales == "" {
+		problems = append(problems, "bot.locales: directory with the locales is required")
+	}
+	if config.Bot.PollTimeout <= 0 {
+		problems = append(problems, "bot.poll_timeout: must be positive")
+	}
+
+	problems = append(problems, config.Webhook.validate()...)
+	problems = append(problems, validateDatabase(config.Database)...)
+
+	// The players need some time to make the turn, to fill the lobby and to use the join code.
+	durations := []struct {
+		key        string
+		duration   time.Duration
+		isPositive bool
+	}{
+		{"game.turn_timeout", config.Game.TurnTimeout, true},
+		{"game.game_timeout", config.Game.GameTimeout, false},
+		{"game.lobby_timeout", config.Game.LobbyTimeout, true},
+		{"game.reminder", config.Game.Reminder, true},
+		{"game.queue_mix_after", config.Game.QueueMixAfter, false},
+		{"game.join_code_lifetime", config.Game.JoinCodeLifetime, true},
+		{"ai.timeout", config.AI.Timeout, false},
+	}
+
+	for _, d := range durations {
+		switch {
+		case d.isPositive && d.duration <= 0:
+			problems = append(problems, d.key+": must be positive")
+		case d.duration < 0:
+			problems = append(problems, d.key+": must not be negative")
+		}
+	}
+
+	// The players are reminded before the deadline, not after it.
+	if config.Game.Reminder >= config.Game.TurnTimeout {
+		problems = append(problems, "game.reminder: must be less than game.turn_timeout")
+	}
+	if config.Game.Reminder >= config.Game.LobbyTimeout {
+		problems = append(problems, "game.reminder: must be less than game.lobby_timeout")
+	}
+
+	if _, err := config.Game.Settings(); err != nil {
+		problems = append(problems, err.(Errors)...)
+	}
+
+	switch config.AI.Backend {
+	case "", "scripted":
+	case "http":
+		if config.AI.URL == "" {
+			problems = append(problems, "ai.url is required for the http backend")
+		}
+	case "markov":
+		if _, err := os.Stat(config.AI.Corpus); err != nil {
+			problems = append(problems, "ai.corpus: "+err.Error())
+		}
+	default:
+		problems = append(problems, "ai.backend: must be http, scripted or markov")
+	}
+
+	return problems
+}
+
+// validateDatabase checks that the chosen database can be opened.
+func validateDatabase(database db.Config) Errors {
+	switch database.Driver {
+	case "postgres":
+		if database.Host == "" || database.Name == "" {
+			return Errors{"db.host and db.dbname are required for postgres"}
+		}
+	case "sqlite":
+		if database.Path == "" {
+			return Errors{"db.path is required for sqlite"}
+		}
+	case "memory":
+	default:
+		return Errors{"db.driver: must be postgres, sqlite or memory"}
+	}
+
+	return nil
+}
+
+// Settings returns the rules new games are created with,
+// all the problems are returned as Errors.
+func (game GameConfig) Settings() (gs.Settings, error) {
+	settings := gs.DefaultSettings()
+	var problems Errors
+
+	settings.TurnTimeout = game.TurnTimeout
+	settings.GameTimeout = game.GameTimeout
+	settings.LobbyTimeout = game.LobbyTimeout
+	settings.Reminder = game.Reminder
+
+	settings.Rounds = game.Rounds
+	if game.Rounds < 0 || game.Rounds > gs.MaxRounds {
+		problems = append(problems, fmt.Sprintf("game.rounds: must be a number from 0 to %d", gs.MaxRounds))
+	}
+
+	settings.MaxSpectators = game.MaxSpectators
+	if game.MaxSpectators < 0 {
+		problems = append(problems, "game.max_spectators: must not be negative")
+	}
+
+	policy, isKnown := gs.ParseEditPolicy(game.Edits)
+	if !isKnown {
+		problems = append(problems, "game.edits: must be reject or forward")
+	}
+	settings.Edits = policy
+
+	modes := map[string]gs.GameMode{"media_classic": gs.ClassicMode, "media_machine": gs.MachineMode}
+	lists := map[string][]string{"media_classic": game.MediaClassic, "media_machine": game.MediaMachine}
+	for key, mode := range modes {
+		media, err := mediaTypes(lists[key])
+		if err != nil {
+			problems = append(problems, "game."+key+": "+err.Error())
+		}
+
+		settings.AllowedMedia[mode] = media
+	}
+
+	if len(problems) > 0 {
+		return settings, problems
+	}
+
+	return settings, nil
+}
+
+// mediaTypes checks the names of the kinds of the files.
+func mediaTypes(names []string) ([]gs.MediaType, error) {
+	media := []gs.MediaType{}
+	for _, name := range names {
+		switch mediaType := gs.MediaType(name); mediaType {
+		case gs.Photo, gs.Video, gs.VideoNote, gs.Animation, gs.Audio, gs.Voice, gs.Document, gs.Sticker:
+			media = append(media, mediaType)
+		default:
+			return nil, fmt.Errorf("unknown kind of the file %q", name)
+		}
+	}
+
+	return media, nil
+}
+
+// mediaNames returns the names of the kinds of the files.
+func mediaNames(media []gs.MediaType) []string {
+	names := []string{}
+	for _, mediaType := range media {
+		names = append(names, string(mediaType))
+	}
+
+	return names
+}
+
+// validate returns the problems of the webhook, none if it is disabled.
+func (webhook WebhookConfig) validate() Errors {
+	var problems Errors
+
+	if !webhook.IsEnabled() {
+		if webhook != (WebhookConfig{}) {
+			problems = append(problems, "webhook.listen is required to use the webhook")
+		}
+		return problems
+	}
+
+	if (webhook.TLSCert == "") != (webhook.TLSKey == "") {
+		problems = append(problems, "webhook.tls_cert and webhook.tls_key must be set together")
+	}
+	// Otherwise telegram is told to send the updates to the listen address
+	// that usually has no host, e.g. https://:8443.
+	if webhook.PublicURL == "" {
+		problems = append(problems, "webhook.public_url is required to use the webhook")
+	}
+	if webhook.PublicURL != "" && !strings.HasPrefix(webhook.PublicURL, "https://") {
+		problems = append(problems, "webhook.public_url: telegram sends the updates only to https")
+	}
+	if webhook.Secret != "" && !webhookSecret.MatchString(webhook.Secret) {
+		problems = append(problems, "webhook.secret: must be 1-256 letters, digits, _ or -")
+	}
+
+	return problems
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes the configuration file to the temporary directory.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestLoadDatabase(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		env        map[string]string
+		wantDriver string
+		wantPath   string // wantPath is relative to the directory of the file.
+		wantErr    bool
+	}{
+		{
+			name:       "other sections are not checked",
+			content:    `{"bot": {"token": ""}, "game": {"rounds": "many"}, "db": {"driver": "sqlite", "path": "turing.db"}}`,
+			wantDriver: "sqlite",
+			wantPath:   "turing.db",
+		},
+		{
+			name:       "environment overrides the file",
+			content:    `{"db": {"driver": "sqlite", "path": "turing.db"}}`,
+			env:        map[string]string{"TURING_DB_DRIVER": "memory"},
+			wantDriver: "memory",
+			wantPath:   "turing.db",
+		},
+		{
+			name:    "unknown setting",
+			content: `{"db": {"driver": "memory"}, "game": {"round": 3}}`,
+			wantErr: true,
+		},
+		{
+			name:    "database without path",
+			content: `{"db": {"driver": "sqlite"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			file := writeConfig(t, test.content)
+
+			database, err := LoadDatabase([]string{"-config", file})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("LoadDatabase() error = %v, want error: %t", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if database.Driver != test.wantDriver || database.Path != filepath.Join(filepath.Dir(file), test.wantPath) {
+				t.Errorf("database = %+v", database)
+			}
+		})
+	}
+}
+
+// validConfig is the smallest configuration Load accepts.
+const validConfig = `{"bot": {"token": "test"}, "db": {"driver": "memory"}}`
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		env        string // env is the value of TURING_GAME_ROUNDS, it is not set if empty.
+		flag       string // flag is the value of -game.rounds, it is not passed if empty.
+		wantRounds int
+	}{
+		{"default", validConfig, "", "", Default().Game.Rounds},
+		{"file", `{"bot": {"token": "test"}, "db": {"driver": "memory"}, "game": {"rounds": 3}}`, "", "", 3},
+		{"environment overrides the file", `{"bot": {"token": "test"}, "db": {"driver": "memory"}, "game": {"rounds": 3}}`, "5", "", 5},
+		{"flag overrides the file", `{"bot": {"token": "test"}, "db": {"driver": "memory"}, "game": {"rounds": 3}}`, "", "7", 7},
+		{"flag overrides the environment", `{"bot": {"token": "test"}, "db": {"driver": "memory"}, "game": {"rounds": 3}}`, "5", "7", 7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("TURING_GAME_ROUNDS", test.env)
+			}
+
+			args := []string{"-config", writeConfig(t, test.content)}
+			if test.flag != "" {
+				args = append(args, "-game.rounds", test.flag)
+			}
+
+			config, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Game.Rounds != test.wantRounds {
+				t.Errorf("game.rounds = %d, want %d", config.Game.Rounds, test.wantRounds)
+			}
+		})
+	}
+}
+
+func TestLoadPaths(t *testing.T) {
+	absolute := filepath.Join(t.TempDir(), "locales")
+
+	tests := []struct {
+		name        string
+		locales     string // locales is the value of bot.locales in the file.
+		env         string // env is the value of TURING_BOT_LOCALES, it is not set if empty.
+		wantLocales func(dir string) string
+	}{
+		{"relative to the file", "locales", "", func(dir string) string { return filepath.Join(dir, "locales") }},
+		{"in the parent of the file", "../locales", "", func(dir string) string { return filepath.Join(filepath.Dir(dir), "locales") }},
+		{"absolute", absolute, "", func(string) string { return absolute }},
+		{"environment is relative to the working directory", "locales", "my/locales", func(string) string { return "my/locales" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("TURING_BOT_LOCALES", test.env)
+			}
+
+			file := writeConfig(t, `{"bot": {"token": "test", "locales": "`+test.locales+`"}, "db": {"driver": "memory"}}`)
+
+			config, err := Load([]string{"-config", file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := test.wantLocales(filepath.Dir(file)); config.Bot.Locales != want {
+				t.Errorf("bot.locales = %q, want %q", config.Bot.Locales, want)
+			}
+			if config.File != file {
+				t.Errorf("File = %q, want %q", config.File, file)
+			}
+		})
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string // args are passed to Load after the valid configuration file.
+		content string   // content replaces the valid configuration file if it is not empty.
+		want    string   // want is one of the problems, nothing is expected if it is empty.
+	}{
+		{"valid", nil, "", ""},
+		{"unknown setting", nil, `{"bot": {"token": "test", "name": "turing"}}`, "bot.name in "},
+		{"not a number", []string{"-game.rounds", "many"}, "", "-game.rounds: must be a whole number"},
+		{"not a duration", []string{"-game.turn_timeout", "5"}, "", "-game.turn_timeout: must be a duration"},
+		{"not a boolean", []string{"-bot.strict_locales", "yes"}, "", "-bot.strict_locales: must be true or false"},
+		{"no token", []string{"-bot.token", ""}, "", "bot.token is required"},
+		{"no locales", []string{"-bot.locales", ""}, "", "bot.locales: directory with the locales is required"},
+		{"no poll timeout", []string{"-bot.poll_timeout", "0s"}, "", "bot.poll_timeout: must be positive"},
+		{"unknown driver", []string{"-db.driver", "mysql"}, "", "db.driver: must be postgres, sqlite or memory"},
+		{"postgres without host", []string{"-db.driver", "postgres"}, "", "db.host and db.dbname are required for postgres"},
+		{"sqlite without path", []string{"-db.driver", "sqlite"}, "", "db.path is required for sqlite"},
+		{"no turn timeout", []string{"-game.turn_timeout", "0s"}, "", "game.turn_timeout: must be positive"},
+		{"negative game timeout", []string{"-game.game_timeout", "-1s"}, "", "game.game_timeout: must not be negative"},
+		{"no lobby timeout", []string{"-game.lobby_timeout", "0s"}, "", "game.lobby_timeout: must be positive"},
+		{"no reminder", []string{"-game.reminder", "0s"}, "", "game.reminder: must be positive"},
+		{"negative queue mix", []string{"-game.queue_mix_after", "-1s"}, "", "game.queue_mix_after: must not be negative"},
+		{"no join code lifetime", []string{"-game.join_code_lifetime", "0s"}, "", "game.join_code_lifetime: must be positive"},
+		{"negative ai timeout", []string{"-ai.timeout", "-1s"}, "", "ai.timeout: must not be negative"},
+		{"reminder after the turn", []string{"-game.reminder", "10m", "-game.turn_timeout", "10m"}, "", "game.reminder: must be less than game.turn_timeout"},
+		{"reminder after the lobby", []string{"-game.reminder", "30m", "-game.turn_timeout", "1h"}, "", "game.reminder: must be less than game.lobby_timeout"},
+		{"too many rounds", []string{"-game.rounds", "1000"}, "", "game.rounds: must be a number from 0 to"},
+		{"negative spectators", []string{"-game.max_spectators", "-1"}, "", "game.max_spectators: must not be negative"},
+		{"unknown edit policy", []string{"-game.edits", "ignore"}, "", "game.edits: must be reject or forward"},
+		{"unknown media", []string{"-game.media_classic", "hologram"}, "", "game.media_classic: "},
+		{"unknown backend", []string{"-ai.backend", "oracle"}, "", "ai.backend: must be http, scripted or markov"},
+		{"http backend without url", []string{"-ai.backend", "http"}, "", "ai.url is required for the http backend"},
+		{"markov backend without corpus", []string{"-ai.backend", "markov", "-ai.corpus", "missing.txt"}, "", "ai.corpus: "},
+		{"webhook without listen", []string{"-webhook.public_url", "https://bot.example.com"}, "", "webhook.listen is required to use the webhook"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := test.content
+			if content == "" {
+				content = validConfig
+			}
+
+			_, err := Load(append([]string{"-config", writeConfig(t, content)}, test.args...))
+			if test.want == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Load() error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -3,11 +3,12 @@
 package config
 
 import (
-	"encoding/json"
-	"log"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/dzendos/Turing/backend"
 	cmd_handler "github.com/dzendos/Turing/command_handler"
 	lcl "github.com/dzendos/Turing/config/locales"
 	db "github.com/dzendos/Turing/database"
@@ -15,39 +16,130 @@ import (
 	tb "gopkg.in/telebot.v3"
 )
 
-// InitializeBot tries to connect the bot with
-// our token.
-func InitializeBot() (*tb.Bot, error) {
-	db.Init()
+// playerBackend creates the backend for bot players from the "ai" section
+// of the configuration, nil is returned if the backend is not specified.
+func playerBackend(ai AIConfig) (gs.PlayerBackend, error) {
+	switch ai.Backend {
+	case "":
+		return nil, nil
+	case "http":
+		return backend.NewHTTPBackend(ai.URL, ai.Model, ai.APIKey), nil
+	case "scripted":
+		return backend.NewScriptedBackend(strings.Split(ai.Replies, "|")), nil
+	case "markov":
+		corpus, err := os.ReadFile(ai.Corpus)
+		if err != nil {
+			return nil, fmt.Errorf("ai.corpus: %w", err)
+		}
+
+		markov, err := backend.NewMarkovBackend(string(corpus), time.Now().UnixNano())
+		if err != nil {
+			return nil, err
+		}
+
+		return markov, nil
+	}
+
+	return nil, fmt.Errorf("ai.backend: unknown backend %q", ai.Backend)
+}
+
+// poller returns the webhook if it is configured,
+// otherwise the bot polls telegram for the updates.
+func poller(config Config) tb.Poller {
+	webhook := config.Webhook
+	if !webhook.IsEnabled() {
+		return &tb.LongPoller{Timeout: config.Bot.PollTimeout}
+	}
+
+	poller := &tb.Webhook{
+		Listen:      webhook.Listen,
+		SecretToken: webhook.Secret,
+		Endpoint:    &tb.WebhookEndpoint{PublicURL: webhook.PublicURL, Cert: webhook.PublicCert},
+	}
 
-	jsonDict, _ := os.ReadFile("config/config.json")
+	if webhook.TLSCert != "" {
+		poller.TLS = &tb.WebhookTLS{Cert: webhook.TLSCert, Key: webhook.TLSKey}
+	}
 
-	var configs map[string]map[string]string
-	err := json.Unmarshal(jsonDict, &configs)
+	return poller
+}
 
+// InitializeBot tries to connect the bot with
+// our token.
+func InitializeBot(config Config) (*tb.Bot, error) {
+	bot, err := tb.NewBot(tb.Settings{
+		URL:    config.Bot.APIURL,
+		Token:  config.Bot.Token,
+		Poller: poller(config),
+	})
 	if err != nil {
-		log.Print(err.Error())
 		return nil, err
 	}
 
-	var token string = string(configs["bot"]["token"])
-	// TODO: add loading token from json
-	return tb.NewBot(tb.Settings{
-		Token:  token,
-		Poller: &tb.LongPoller{Timeout: 10 * time.Second},
-	})
+	// Telegram does not give the updates to the poller while the webhook is set.
+	if !config.Webhook.IsEnabled() {
+		if err := bot.RemoveWebhook(); err != nil {
+			return nil, fmt.Errorf("removing the webhook: %w", err)
+		}
+	}
+
+	return bot, nil
 }
 
 // InitializeBotHandler connects bot with all handle
 // methods we have.
-func InitializeBotHandler(bot *tb.Bot) {
-	botHandler := cmd_handler.BotHandler{Bot: bot, Local: lcl.NewLocalizer(), CurrentPlayers: make(map[int64]*gs.Player)}
+func InitializeBotHandler(bot *tb.Bot, storage db.Storage, config Config) error {
+	settings, err := config.Game.Settings()
+	if err != nil {
+		return err
+	}
+
+	local, err := lcl.NewLocalizer(config.Bot.Locales, config.Bot.StrictLocales)
+	if err != nil {
+		return fmt.Errorf("locales: %w", err)
+	}
+
+	botHandler := cmd_handler.BotHandler{Bot: bot, Local: local, Games: gs.NewRegistry(), Storage: storage, Settings: settings}
+	botHandler.Games.MixLanguagesAfter = config.Game.QueueMixAfter
+	botHandler.Games.JoinCodeLifetime = config.Game.JoinCodeLifetime
+
+	botHandler.Backend, err = playerBackend(config.AI)
+	if err != nil {
+		return err
+	}
+	botHandler.BackendTimeout = config.AI.Timeout
+
+	botHandler.Games.OnChange = botHandler.GameChanged
+	botHandler.Games.OnGameOver = botHandler.GameOver
+	botHandler.RestoreGames()
+
+	go botHandler.WatchDeadlines(time.Second)
 
 	bot.Handle("/start", botHandler.CmdStart)
-	bot.Handle("/get_my_id", botHandler.CmdGetMyId)
+	bot.Handle("/join", botHandler.CmdJoin)
+	bot.Handle("/invite", botHandler.CmdInvite)
 	bot.Handle("/new_game", botHandler.CmdNewGame)
+	bot.Handle("/new_machine_game", botHandler.CmdNewMachineGame)
 	bot.Handle("/exit_lobby", botHandler.CmdExitLobby)
 	bot.Handle("/answer", botHandler.CmdAnswer)
-	bot.Handle("/new_random_game", botHandler.CmdNewGame)
+	bot.Handle("/new_random_game", botHandler.CmdPlay)
+	bot.Handle("/play", botHandler.CmdPlay)
+	bot.Handle("/cancel", botHandler.CmdCancel)
+	bot.Handle("/stats", botHandler.CmdStats)
+	bot.Handle("/leaderboard", botHandler.CmdLeaderboard)
+	bot.Handle("/transcript", botHandler.CmdTranscript)
+	bot.Handle("/add_bot", botHandler.CmdAddBot)
+	bot.Handle("/watch", botHandler.CmdWatch)
+	bot.Handle("/unwatch", botHandler.CmdUnwatch)
+	bot.Handle("/spectators", botHandler.CmdSpectators)
+	bot.Handle("/edits", botHandler.CmdEdits)
+	bot.Handle("/language", botHandler.CmdLanguage)
+	bot.Handle(&cmd_handler.GuessBtn, botHandler.GuessHandler)
+	bot.Handle(&cmd_handler.LeaderboardBtn, botHandler.LeaderboardHandler)
+	bot.Handle(&cmd_handler.LanguageBtn, botHandler.LanguageHandler)
 	bot.Handle(tb.OnText, botHandler.MessageHandler)
+	bot.Handle(tb.OnMedia, botHandler.MessageHandler)
+	bot.Handle(tb.OnEdited, botHandler.EditedHandler)
+
+	return nil
 }
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// fakeAPI is the Bot API server that gives the poller one update
+// and remembers the parameters of every call.
+type fakeAPI struct {
+	*httptest.Server
+
+	polled   chan struct{} // polled is closed when the update has been returned by getUpdates.
+	released chan struct{} // released is closed when getUpdates can return nothing.
+
+	mu    sync.Mutex
+	calls map[string][]map[string]interface{} // calls are keyed by the method.
+
+	pollOnce, releaseOnce sync.Once
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	api := &fakeAPI{
+		polled:   make(chan struct{}),
+		released: make(chan struct{}),
+		calls:    make(map[string][]map[string]interface{}),
+	}
+
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&params)
+
+		method := path.Base(r.URL.Path)
+
+		api.mu.Lock()
+		api.calls[method] = append(api.calls[method], params)
+		api.mu.Unlock()
+
+		switch method {
+		case "getMe":
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Turing","username":"turing_bot"}}`)
+		case "getUpdates":
+			isFirst := false
+			api.pollOnce.Do(func() { isFirst = true })
+			if isFirst {
+				fmt.Fprint(w, `{"ok":true,"result":[`+update(1, "polled")+`]}`)
+				close(api.polled)
+				return
+			}
+
+			// Telegram holds the request while there are no updates.
+			select {
+			case <-api.released:
+			case <-r.Context().Done():
+			}
+			fmt.Fprint(w, `{"ok":true,"result":[]}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+
+	// The held requests are released before the server is closed.
+	t.Cleanup(api.Close)
+	t.Cleanup(api.release)
+
+	return api
+}
+
+// release makes the held and the next getUpdates return nothing.
+func (api *fakeAPI) release() {
+	api.releaseOnce.Do(func() { close(api.released) })
+}
+
+// called returns the parameters of the calls of the method.
+func (api *fakeAPI) called(method string) []map[string]interface{} {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	return api.calls[method]
+}
+
+// update returns the update with the private message of the user.
+func update(id int, text string) string {
+	return fmt.Sprintf(`{"update_id":%d,"message":{"message_id":%d,"from":{"id":5,"first_name":"Alice"},"chat":{"id":5,"type":"private"},"text":%q}}`, id, id, text)
+}
+
+// The tests run the poller of the bot themselves: Bot.Start writes
+// the field telebot reads in the goroutines of the requests made by
+// InitializeBot, so the race detector reports it.
+
+func TestLongPoller(t *testing.T) {
+	api := newFakeAPI(t)
+
+	config := Default()
+	config.Bot.Token = "test"
+	config.Bot.APIURL = api.URL
+	config.Bot.PollTimeout = time.Second
+
+	bot, err := InitializeBot(config)
+	if err != nil {
+		t.Fatalf("InitializeBot: %v", err)
+	}
+
+	updates := make(chan tb.Update, 10)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		bot.Poller.Poll(bot, updates, stop)
+		close(stopped)
+	}()
+
+	select {
+	case <-api.polled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the updates are not polled")
+	}
+
+	if u := <-updates; u.Message == nil || u.Message.Text != "polled" {
+		t.Fatalf("received %+v, want the message %q", u, "polled")
+	}
+
+	close(stop)
+	api.release()
+	<-stopped
+
+	if len(api.called("deleteWebhook")) != 1 {
+		t.Errorf("the webhook has not been removed before polling")
+	}
+	if len(api.called("setWebhook")) != 0 {
+		t.Errorf("the webhook has been set in the long polling mode")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	api := newFakeAPI(t)
+
+	config := Default()
+	config.Bot.Token = "test"
+	config.Bot.APIURL = api.URL
+	config.Webhook = WebhookConfig{
+		Listen:    "127.0.0.1:8443",
+		PublicURL: "https://bot.example.com/hook",
+		Secret:    "test-secret",
+	}
+
+	if problems := config.Webhook.validate(); len(problems) > 0 {
+		t.Fatalf("valid webhook is rejected: %v", problems)
+	}
+
+	bot, err := InitializeBot(config)
+	if err != nil {
+		t.Fatalf("InitializeBot: %v", err)
+	}
+
+	webhook, isWebhook := bot.Poller.(*tb.Webhook)
+	if !isWebhook {
+		t.Fatalf("poller is %T, want the webhook", bot.Poller)
+	}
+
+	// The webhook is served by the test, so it does not listen itself.
+	webhook.Listen = ""
+
+	updates := make(chan tb.Update, 10)
+	stop := make(chan struct{})
+	go webhook.Poll(bot, updates, stop)
+
+	// Poll takes the stop signal after the webhook is set, the webhook
+	// goes on handling the requests after that.
+	stop <- struct{}{}
+
+	server := httptest.NewServer(webhook)
+	t.Cleanup(server.Close)
+
+	post := func(id int, secret string) {
+		t.Helper()
+
+		request, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString(update(id, "with "+secret)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+
+	// The update is passed on before the webhook responds.
+	post(1, "wrong")
+	if len(updates) != 0 {
+		t.Fatalf("received %+v with the wrong secret token", <-updates)
+	}
+
+	post(2, "test-secret")
+	if len(updates) != 1 {
+		t.Fatalf("%d updates are received with the secret token, want 1", len(updates))
+	}
+	if u := <-updates; u.Message == nil || u.Message.Text != "with test-secret" {
+		t.Fatalf("received %+v, want the message %q", u, "with test-secret")
+	}
+
+	calls := api.called("setWebhook")
+	if len(calls) != 1 {
+		t.Fatalf("setWebhook is called %d times, want 1", len(calls))
+	}
+	if url := calls[0]["url"]; url != config.Webhook.PublicURL {
+		t.Errorf("webhook is set to %v, want %s", url, config.Webhook.PublicURL)
+	}
+	if secret := calls[0]["secret_token"]; secret != config.Webhook.Secret {
+		t.Errorf("secret token is %v, want %s", secret, config.Webhook.Secret)
+	}
+
+	if len(api.called("getUpdates")) != 0 {
+		t.Errorf("updates are polled in the webhook mode")
+	}
+}
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook WebhookConfig
+		isValid bool
+	}{
+		{"disabled", WebhookConfig{}, true},
+		{"behind ingress", WebhookConfig{Listen: ":8443", PublicURL: "https://bot.example.com/hook", Secret: "s3cr-et"}, true},
+		{"with tls", WebhookConfig{Listen: ":8443", PublicURL: "https://bot.example.com:8443", TLSCert: "cert.pem", TLSKey: "key.pem"}, true},
+		{"without listen", WebhookConfig{PublicURL: "https://bot.example.com/hook"}, false},
+		{"without public url", WebhookConfig{Listen: ":8443", TLSCert: "cert.pem", TLSKey: "key.pem"}, false},
+		{"plain http", WebhookConfig{Listen: ":8443", PublicURL: "http://bot.example.com/hook"}, false},
+		{"cert without key", WebhookConfig{Listen: ":8443", PublicURL: "https://bot.example.com/hook", TLSCert: "cert.pem"}, false},
+		{"bad secret", WebhookConfig{Listen: ":8443", PublicURL: "https://bot.example.com/hook", Secret: "a secret"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := test.webhook.validate()
+			if isValid := len(problems) == 0; isValid != test.isValid {
+				t.Errorf("validate() = %v, want valid: %t", problems, test.isValid)
+			}
+		})
+	}
+}
@@ -1,43 +1,215 @@
+// Package locales provides the texts of the bot in
+// the languages of the users.
 package locales
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
-func localesFile() string {
-	return "config/locales/locales.json"
+// DefaultDir is the directory the locales are kept in
+// if nothing else is specified in the configuration.
+const DefaultDir = "config/locales"
+
+// DefaultLanguage is the last language in every fallback chain.
+const DefaultLanguage = "en"
+
+// Type Params contains the values of the named placeholders
+// of the message, e.g. {name}.
+type Params map[string]interface{}
+
+// Type message is the text of the message in one language,
+// the message with plural forms has no text.
+type message struct {
+	text  string
+	forms map[string]string // forms are keyed by the plural category: zero, one, two, few, many, other.
 }
 
+// UnmarshalJSON reads either the text or the object with plural forms.
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &m.forms); err != nil {
+		return fmt.Errorf("message must be a string or an object with plural forms")
+	}
+
+	if _, isSet := m.forms["other"]; !isSet {
+		return fmt.Errorf("plural form \"other\" is missing")
+	}
+
+	return nil
+}
+
+// Type Localizer keeps the messages of all the locales.
 type Localizer struct {
-	dict map[string]map[string]string
+	dict map[string]map[string]message // dict is keyed by the language tag in lower case.
 }
 
-func (l *Localizer) Get(local string, field string) string {
-	// In case if required localization does not exist
-	// we use English localization as a default one.
-	if local != "en" && local != "ru" {
-		local = "en"
+//go:generate go run github.com/dzendos/Turing locales keys -src ../.. -out keys.go
+
+// NewLocalizer loads every locale from the directory, the name of the
+// file is the language tag of the locale, e.g. pt-BR.json.
+// Every problem of the locales is logged, in the strict mode the keys
+// used in the code must be in the locale of the DefaultLanguage.
+func NewLocalizer(dir string, strict bool) (*Localizer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
 	}
 
-	return l.dict[local][field]
+	local := &Localizer{dict: make(map[string]map[string]message)}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var messages map[string]message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		language := normalize(strings.TrimSuffix(filepath.Base(file), ".json"))
+		local.dict[language] = messages
+	}
+
+	if _, isLoaded := local.dict[DefaultLanguage]; !isLoaded {
+		return nil, fmt.Errorf("there is no locale %q in %s", DefaultLanguage, dir)
+	}
+
+	report := local.Validate(UsedKeys)
+	for _, problem := range report.Problems() {
+		log.Print("locales: ", problem)
+	}
+
+	if strict && len(report.Unknown) > 0 {
+		return nil, fmt.Errorf("%d keys used in the code are not in %s", len(report.Unknown), DefaultLanguage)
+	}
+
+	return local, nil
+}
+
+// Key marks the key of the message that is kept in a variable before it
+// is passed to the localizer, so ScanKeys finds it. The key is returned as is.
+func Key(key string) string {
+	return key
 }
 
-func NewLocalizer() *Localizer {
-	var local Localizer
+// normalize brings the language tag to the form the locales are kept with.
+func normalize(language string) string {
+	return strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+}
 
-	jsonDict, errFile := os.ReadFile(localesFile())
+// fallbacks returns the languages the message is looked for in,
+// e.g. pt-BR, pt and then the default language.
+func fallbacks(language string) []string {
+	var chain []string
+	for tag := normalize(language); tag != ""; {
+		chain = append(chain, tag)
 
-	if errFile != nil {
-		log.Print(errFile.Error())
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
 	}
 
-	err := json.Unmarshal(jsonDict, &local.dict)
+	return append(chain, DefaultLanguage)
+}
 
-	if err != nil {
-		log.Print(err.Error())
+// Languages returns the tags of all the loaded locales.
+func (l *Localizer) Languages() []string {
+	var languages []string
+	for language := range l.dict {
+		languages = append(languages, language)
+	}
+
+	sort.Strings(languages)
+	return languages
+}
+
+// Has checks if there is the locale for the language, without fallbacks.
+func (l *Localizer) Has(language string) bool {
+	_, isLoaded := l.dict[normalize(language)]
+	return isLoaded
+}
+
+// lookup finds the message in the first locale of the fallback
+// chain that has it, the locale is returned with the message.
+func (l *Localizer) lookup(language string, key string) (message, string, bool) {
+	for _, tag := range fallbacks(language) {
+		if msg, isFound := l.dict[tag][key]; isFound {
+			return msg, tag, true
+		}
+	}
+
+	log.Printf("locales: message %q is missing", key)
+	return message{}, "", false
+}
+
+// Get returns the message in the language of the user.
+// The key itself is returned if no locale has the message.
+func (l *Localizer) Get(language string, key string) string {
+	return l.Format(language, key, nil)
+}
+
+// Format returns the message with its placeholders replaced by the params.
+func (l *Localizer) Format(language string, key string, params Params) string {
+	msg, _, isFound := l.lookup(language, key)
+	if !isFound {
+		return key
+	}
+
+	text := msg.text
+	if msg.forms != nil {
+		text = msg.forms["other"]
+	}
+
+	return replace(text, params)
+}
+
+// Plural returns the form of the message that agrees with the count,
+// the count is available in the message as {count}.
+func (l *Localizer) Plural(language string, key string, count int, params Params) string {
+	msg, tag, isFound := l.lookup(language, key)
+	if !isFound {
+		return key
+	}
+
+	all := Params{"count": count}
+	for name, value := range params {
+		all[name] = value
+	}
+
+	if msg.forms == nil {
+		return replace(msg.text, all)
+	}
+
+	text, hasForm := msg.forms[pluralCategory(tag, count)]
+	if !hasForm {
+		text = msg.forms["other"]
+	}
+
+	return replace(text, all)
+}
+
+// replace puts the params into the placeholders of the text.
+func replace(text string, params Params) string {
+	if len(params) == 0 {
+		return text
+	}
+
+	var pairs []string
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
 	}
 
-	return &local
+	return strings.NewReplacer(pairs...).Replace(text)
 }
@@ -0,0 +1,96 @@
+package locales
+
+import "strings"
+
+// Type pluralRule chooses the plural category of the number.
+type pluralRule func(n int) string
+
+// oneOther is the rule of English and most of the European languages.
+func oneOther(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// zeroOneOther is the rule of French and Portuguese, where 0 is singular too.
+func zeroOneOther(n int) string {
+	if n == 0 || n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// eastSlavic is the rule of Russian, Ukrainian and Belarusian.
+func eastSlavic(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "one"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+// polish is the rule of Polish, it differs from eastSlavic only in 1.
+func polish(n int) string {
+	switch {
+	case n == 1:
+		return "one"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+// czech is the rule of Czech and Slovak.
+func czech(n int) string {
+	switch {
+	case n == 1:
+		return "one"
+	case n >= 2 && n <= 4:
+		return "few"
+	}
+	return "other"
+}
+
+// other is the rule of the languages without plural forms.
+func other(int) string {
+	return "other"
+}
+
+// pluralRules are keyed by the language without the region,
+// oneOther is used for the languages that are not here.
+var pluralRules = map[string]pluralRule{
+	"fr": zeroOneOther,
+	"pt": zeroOneOther,
+	"ru": eastSlavic,
+	"uk": eastSlavic,
+	"be": eastSlavic,
+	"pl": polish,
+	"cs": czech,
+	"sk": czech,
+	"ja": other,
+	"ko": other,
+	"zh": other,
+	"id": other,
+	"vi": other,
+	"th": other,
+}
+
+// pluralCategory returns the plural category of the number in the language.
+func pluralCategory(language string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	if i := strings.Index(language, "-"); i >= 0 {
+		language = language[:i]
+	}
+
+	if rule, isKnown := pluralRules[language]; isKnown {
+		return rule(n)
+	}
+
+	return oneOther(n)
+}
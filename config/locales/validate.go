@@ -0,0 +1,246 @@
+package locales
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholder matches the named placeholders of the messages, e.g. {name}.
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Type Mismatch is the message which placeholders differ from the reference locale.
+type Mismatch struct {
+	Language string
+	Key      string
+	Want     []string
+	Got      []string
+}
+
+// Type Report contains everything wrong with the locales,
+// every locale is compared with the reference one.
+type Report struct {
+	Reference  string
+	Missing    map[string][]string // Missing contains the keys the locale does not have, it is keyed by the language.
+	Extra      map[string][]string // Extra contains the keys only the locale has, it is keyed by the language.
+	Mismatches []Mismatch
+	Unknown    []string // Unknown contains the keys used in the code that the reference locale does not have.
+}
+
+// IsEmpty checks if nothing is wrong with the locales.
+func (r Report) IsEmpty() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Mismatches) == 0 && len(r.Unknown) == 0
+}
+
+// Problems describes everything in the report, one problem per line.
+func (r Report) Problems() []string {
+	var problems []string
+	for _, key := range r.Unknown {
+		problems = append(problems, "key "+strconv.Quote(key)+" is used in the code, but "+r.Reference+" does not have it")
+	}
+
+	for _, language := range sortedKeys(r.Missing) {
+		for _, key := range r.Missing[language] {
+			problems = append(problems, language+": key "+strconv.Quote(key)+" is missing")
+		}
+	}
+
+	for _, language := range sortedKeys(r.Extra) {
+		for _, key := range r.Extra[language] {
+			problems = append(problems, language+": key "+strconv.Quote(key)+" is not in "+r.Reference)
+		}
+	}
+
+	for _, mismatch := range r.Mismatches {
+		problems = append(problems, mismatch.Language+": key "+strconv.Quote(mismatch.Key)+" has placeholders {"+
+			strings.Join(mismatch.Got, "}, {")+"} instead of {"+strings.Join(mismatch.Want, "}, {")+"}")
+	}
+
+	return problems
+}
+
+// Validate compares every locale with the reference one and looks for
+// the keys used in the code that the reference locale does not have.
+func (l *Localizer) Validate(used []string) Report {
+	report := Report{
+		Reference: DefaultLanguage,
+		Missing:   make(map[string][]string),
+		Extra:     make(map[string][]string),
+	}
+
+	reference := l.dict[DefaultLanguage]
+	for _, key := range used {
+		if _, isFound := reference[key]; !isFound {
+			report.Unknown = append(report.Unknown, key)
+		}
+	}
+
+	for _, language := range l.Languages() {
+		if language == DefaultLanguage {
+			continue
+		}
+
+		messages := l.dict[language]
+		for _, key := range sortedKeys(reference) {
+			msg, isFound := messages[key]
+			if !isFound {
+				report.Missing[language] = append(report.Missing[language], key)
+				continue
+			}
+
+			want, got := reference[key].placeholders(), msg.placeholders()
+			if strings.Join(want, ",") != strings.Join(got, ",") {
+				report.Mismatches = append(report.Mismatches, Mismatch{language, key, want, got})
+			}
+		}
+
+		for _, key := range sortedKeys(messages) {
+			if _, isFound := reference[key]; !isFound {
+				report.Extra[language] = append(report.Extra[language], key)
+			}
+		}
+	}
+
+	return report
+}
+
+// placeholders returns the sorted names of the placeholders
+// used in the text or in any plural form of the message.
+func (m message) placeholders() []string {
+	texts := []string{m.text}
+	for _, form := range m.forms {
+		texts = append(texts, form)
+	}
+
+	names := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			names[match[1]] = true
+		}
+	}
+
+	return sortedKeys(names)
+}
+
+// ScanKeys finds the keys of the messages used in the Go files of the
+// directory: the string literals passed to Get, Format and Plural of the
+// localizer or marked with Key. Keys that are built while the bot is
+// running cannot be found, so they must be marked with Key where they are written.
+func ScanKeys(dir string) ([]string, error) {
+	keys := make(map[string]bool)
+	files := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != dir && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "vendor" || entry.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(files, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			if key, isKey := localizedKey(node); isKey {
+				keys[key] = true
+			}
+			return true
+		})
+
+		return nil
+	})
+
+	return sortedKeys(keys), err
+}
+
+// localizedKey returns the key if the node is the call of the localizer
+// or of Key with the key written as a string literal.
+func localizedKey(node ast.Node) (string, bool) {
+	call, isCall := node.(*ast.CallExpr)
+	if !isCall {
+		return "", false
+	}
+
+	if isKeyMark(call.Fun) && len(call.Args) == 1 {
+		return stringLiteral(call.Args[0])
+	}
+
+	selector, isSelector := call.Fun.(*ast.SelectorExpr)
+	if !isSelector || len(call.Args) < 2 {
+		return "", false
+	}
+
+	switch selector.Sel.Name {
+	case "Get", "Format", "Plural":
+	default:
+		return "", false
+	}
+
+	// The localizer is always called local in the code.
+	var receiver string
+	switch x := selector.X.(type) {
+	case *ast.Ident:
+		receiver = x.Name
+	case *ast.SelectorExpr:
+		receiver = x.Sel.Name
+	}
+
+	if !strings.EqualFold(receiver, "local") {
+		return "", false
+	}
+
+	return stringLiteral(call.Args[1])
+}
+
+// isKeyMark checks if the function is Key of this package,
+// it is called lcl outside of it.
+func isKeyMark(fun ast.Expr) bool {
+	switch x := fun.(type) {
+	case *ast.Ident:
+		return x.Name == "Key"
+	case *ast.SelectorExpr:
+		pkg, isIdent := x.X.(*ast.Ident)
+		return isIdent && x.Sel.Name == "Key" && (pkg.Name == "lcl" || pkg.Name == "locales")
+	}
+
+	return false
+}
+
+// stringLiteral returns the value of the string literal.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	literal, isLiteral := expr.(*ast.BasicLit)
+	if !isLiteral || literal.Kind != token.STRING {
+		return "", false
+	}
+
+	value, err := strconv.Unquote(literal.Value)
+	return value, err == nil
+}
+
+// sortedKeys returns the sorted keys of the map.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
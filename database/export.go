@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// Type SessionFilter chooses the decided games to export.
+// The zero values mean there is no restriction.
+type SessionFilter struct {
+	From     time.Time // From is the first day of the games in UTC.
+	To       time.Time // To is the last day of the games in UTC.
+	Language string    // Language is the language of the host.
+	HostWon  *bool     // HostWon chooses the games by their outcome.
+}
+
+// Type Session is the decided game with the language it was played in.
+type Session struct {
+	Transcript gs.Transcript
+	Language   string // Language is the language of the host.
+}
+
+// Sessions returns all the decided games that match the filter,
+// the games somebody has left have no outcome to learn from.
+func (storage *SQLStorage) Sessions(filter SessionFilter) ([]Session, error) {
+	query := `SELECT g.id, p.language_code FROM game_session g JOIN players p ON p.id = g.host_id WHERE g.was_decided`
+
+	var args []interface{}
+	condition := func(sql string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+sql, len(args))
+	}
+
+	if !filter.From.IsZero() {
+		condition("g.date_start >= $%d", filter.From.Format("2006-01-02"))
+	}
+	if !filter.To.IsZero() {
+		condition("g.date_start <= $%d", filter.To.Format("2006-01-02"))
+	}
+	if filter.Language != "" {
+		condition("p.language_code = $%d", filter.Language)
+	}
+	if filter.HostWon != nil {
+		condition("g.host_won = $%d", *filter.HostWon)
+	}
+
+	rows, err := storage.db.Query(query+" ORDER BY g.id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("select sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var id int64
+		var session Session
+		if err := rows.Scan(&id, &session.Language); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+
+		session.Transcript.GameId = id
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select sessions: %w", err)
+	}
+
+	// The connection is free only when all the rows have been read.
+	rows.Close()
+
+	for i := range sessions {
+		sessions[i].Transcript, err = storage.Transcript(sessions[i].Transcript.GameId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sessions, nil
+}
+
+// matches checks if the decided game matches the filter.
+func (filter SessionFilter) matches(start time.Time, language string, hostWon bool) bool {
+	day := start.Format("2006-01-02")
+
+	switch {
+	case !filter.From.IsZero() && day < filter.From.Format("2006-01-02"):
+		return false
+	case !filter.To.IsZero() && day > filter.To.Format("2006-01-02"):
+		return false
+	case filter.Language != "" && language != filter.Language:
+		return false
+	case filter.HostWon != nil && hostWon != *filter.HostWon:
+		return false
+	}
+
+	return true
+}
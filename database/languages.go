@@ -0,0 +1,36 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Language returns the language the user has chosen,
+// it is empty if he has not chosen any.
+func (storage *SQLStorage) Language(userId int64) (string, error) {
+	var language string
+	err := storage.db.QueryRow(`SELECT language FROM user_languages WHERE id_player = $1`, userId).Scan(&language)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("select language: %w", err)
+	}
+
+	return language, nil
+}
+
+// SetLanguage remembers the language the user has chosen.
+func (storage *SQLStorage) SetLanguage(userId int64, language string) error {
+	_, err := storage.db.Exec(
+		`INSERT INTO user_languages (id_player, language) VALUES ($1, $2)
+		ON CONFLICT (id_player) DO UPDATE SET language = EXCLUDED.language`,
+		userId, language,
+	)
+	if err != nil {
+		return fmt.Errorf("save language: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// Type sessionRecord is a game kept by MemoryStorage,
+// it has the same data as game_session and messages tables.
+type sessionRecord struct {
+	Id            int64
+	HostId        int64
+	KnightId      int64
+	KnaveId       int64
+	Start         time.Time
+	WasSuccesfull bool
+	WasFinished   bool
+	WasDecided    bool
+	Mode          string
+	HostWon       bool
+
+	Players  []sessionPlayerRecord
+	Messages []messageRecord
+}
+
+type sessionPlayerRecord struct {
+	User     gs.User
+	Role     string
+	NickName string
+}
+
+type messageRecord struct {
+	PlayerId      int64
+	TimeFromStart uint64
+	Message       string
+	Role          string
+	MediaType     gs.MediaType
+	FileID        string
+}
+
+// MemoryStorage is the Storage that keeps everything in memory,
+// so it is forgotten when the bot stops. It is useful
+// for running the bot locally.
+type MemoryStorage struct {
+	mu        sync.Mutex
+	sessions  []sessionRecord
+	snapshots map[int64]gs.Snapshot
+	ratings   map[gs.PlayerRole]map[int64]*RatingEntry
+	languages map[int64]string
+}
+
+// NewMemoryStorage creates empty storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		snapshots: make(map[int64]gs.Snapshot),
+		languages: make(map[int64]string),
+		ratings: map[gs.PlayerRole]map[int64]*RatingEntry{
+			gs.Host:  make(map[int64]*RatingEntry),
+			gs.Knave: make(map[int64]*RatingEntry),
+		},
+	}
+}
+
+// SaveGame stores the game with all the messages of its players
+// and returns the id the game is kept with.
+func (storage *MemoryStorage) SaveGame(state *gs.GameState) (int64, error) {
+	mode, err := modeName(state.Mode)
+	if err != nil {
+		return 0, err
+	}
+
+	record := sessionRecord{
+		Mode:          mode,
+		HostWon:       state.HostWon,
+		HostId:        state.Host.User.ID,
+		KnightId:      state.Knight.User.ID,
+		KnaveId:       state.Knave.User.ID,
+		Start:         state.BegginingDate.UTC(),
+		WasSuccesfull: state.WasGameSuccesfull,
+		WasFinished:   state.WasGameFinished,
+		WasDecided:    state.WasGameDecided,
+	}
+
+	for _, player := range []*gs.Player{state.Host, state.Knave, state.Knight} {
+		role, err := roleName(player.Role)
+		if err != nil {
+			return 0, err
+		}
+
+		record.Players = append(record.Players, sessionPlayerRecord{*player.User, role, player.NickName})
+
+		for _, message := range player.History {
+			record.Messages = append(record.Messages, messageRecord{
+				player.User.ID,
+				message.TimeFromTheBeg,
+				message.Message,
+				role,
+				message.MediaType,
+				message.FileID,
+			})
+		}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	record.Id = int64(len(storage.sessions)) + 1
+	storage.sessions = append(storage.sessions, record)
+
+	if isRated(state) {
+		host := storage.rating(gs.Host, state.Host.User)
+		knave := storage.rating(gs.Knave, state.Knave.User)
+
+		host.Rating, knave.Rating = elo(host.Rating, knave.Rating, state.HostWon)
+		host.Games++
+		knave.Games++
+	}
+
+	return record.Id, nil
+}
+
+// rating returns the rating of the user in the role, the new one is
+// created if the user has not been rated yet.
+func (storage *MemoryStorage) rating(role gs.PlayerRole, user *gs.User) *RatingEntry {
+	entry, isRated := storage.ratings[role][user.ID]
+	if !isRated {
+		entry = &RatingEntry{Rating: InitialRating}
+		storage.ratings[role][user.ID] = entry
+	}
+
+	entry.User = *user
+	return entry
+}
+
+// Leaderboard returns the players with the best ratings in the role
+// starting from the offset and the number of all the rated players.
+func (storage *MemoryStorage) Leaderboard(role gs.PlayerRole, offset int, limit int) ([]RatingEntry, int, error) {
+	if _, err := ratingRole(role); err != nil {
+		return nil, 0, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var entries []RatingEntry
+	for _, entry := range storage.ratings[role] {
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Rating != entries[j].Rating {
+			return entries[i].Rating > entries[j].Rating
+		}
+		if entries[i].Games != entries[j].Games {
+			return entries[i].Games > entries[j].Games
+		}
+		return entries[i].User.ID < entries[j].User.ID
+	})
+
+	total := len(entries)
+	if offset > total {
+		offset = total
+	}
+	if offset+limit < total {
+		entries = entries[offset : offset+limit]
+	} else {
+		entries = entries[offset:]
+	}
+
+	return entries, total, nil
+}
+
+// SaveSnapshot stores the snapshot replacing the previous one of the same game.
+func (storage *MemoryStorage) SaveSnapshot(snapshot gs.Snapshot) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	storage.snapshots[snapshot.Id] = snapshot
+	return nil
+}
+
+// DeleteSnapshot forgets the game that is over.
+func (storage *MemoryStorage) DeleteSnapshot(id int64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	delete(storage.snapshots, id)
+	return nil
+}
+
+// LoadSnapshots returns all the stored snapshots.
+func (storage *MemoryStorage) LoadSnapshots() ([]gs.Snapshot, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var snapshots []gs.Snapshot
+	for _, snapshot := range storage.snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Id < snapshots[j].Id
+	})
+
+	return snapshots, nil
+}
+
+// PlayerStats returns the statistics of the user, it is empty
+// if the user has not played yet.
+func (storage *MemoryStorage) PlayerStats(userId int64) (PlayerStats, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	stats := PlayerStats{Roles: make(map[gs.PlayerRole]RoleStats)}
+
+	var guesses, questions, answers int
+	var response uint64
+
+	for _, session := range storage.sessions {
+		for _, player := range session.Players {
+			if player.User.ID != userId {
+				continue
+			}
+
+			role, isKnown := roleByName(player.Role)
+			if !isKnown {
+				continue
+			}
+
+			roleStats := stats.Roles[role]
+			roleStats.Games++
+			if session.WasDecided {
+				roleStats.Decided++
+				if session.HostWon == winsWithHost(role) {
+					roleStats.Wins++
+				}
+			}
+			stats.Roles[role] = roleStats
+		}
+
+		if session.HostId == userId && session.WasSuccesfull {
+			guesses++
+		}
+
+		// Messages are kept in the order of the players, so
+		// the questions of the host are looked for every time.
+		for _, message := range session.Messages {
+			if message.PlayerId != userId {
+				continue
+			}
+
+			if message.Role == "host" {
+				if session.WasSuccesfull {
+					questions++
+				}
+				continue
+			}
+
+			var question uint64
+			isAsked := false
+			for _, other := range session.Messages {
+				if other.Role == "host" && other.TimeFromStart <= message.TimeFromStart && other.TimeFromStart >= question {
+					question = other.TimeFromStart
+					isAsked = true
+				}
+			}
+
+			if isAsked {
+				response += message.TimeFromStart - question
+				answers++
+			}
+		}
+	}
+
+	if guesses > 0 {
+		stats.AverageQuestions = float64(questions) / float64(guesses)
+	}
+
+	if answers > 0 {
+		stats.AverageResponse = time.Duration(float64(response) / float64(answers) * float64(time.Second))
+	}
+
+	return stats, nil
+}
+
+// Transcript returns the transcript of the stored game.
+func (storage *MemoryStorage) Transcript(id int64) (gs.Transcript, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if id < 1 || id > int64(len(storage.sessions)) {
+		return gs.Transcript{}, ErrNotFound
+	}
+
+	return transcript(storage.sessions[id-1]), nil
+}
+
+// Sessions returns all the decided games that match the filter.
+func (storage *MemoryStorage) Sessions(filter SessionFilter) ([]Session, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var sessions []Session
+	for _, session := range storage.sessions {
+		language := session.Players[0].User.LanguageCode
+		if !session.WasDecided || !filter.matches(session.Start, language, session.HostWon) {
+			continue
+		}
+
+		sessions = append(sessions, Session{Transcript: transcript(session), Language: language})
+	}
+
+	return sessions, nil
+}
+
+// transcript creates the transcript of the stored game.
+func transcript(session sessionRecord) gs.Transcript {
+	transcript := gs.Transcript{
+		GameId:  session.Id,
+		Mode:    session.Mode,
+		Start:   session.Start,
+		Decided: session.WasDecided,
+		HostWon: session.HostWon,
+	}
+
+	for _, player := range session.Players {
+		transcript.Players = append(transcript.Players, gs.TranscriptPlayer{
+			UserID:   player.User.ID,
+			Name:     player.User.FirstName,
+			NickName: player.NickName,
+			Role:     player.Role,
+			IsBot:    player.User.IsBot,
+		})
+	}
+
+	histories := make(map[int64][]gs.MessageHistory)
+	for _, message := range session.Messages {
+		histories[message.PlayerId] = append(histories[message.PlayerId], gs.MessageHistory{
+			Message:        message.Message,
+			TimeFromTheBeg: message.TimeFromStart,
+			MediaType:      message.MediaType,
+			FileID:         message.FileID,
+		})
+	}
+
+	return gs.NewTranscript(transcript, histories)
+}
+
+// Language returns the language the user has chosen,
+// it is empty if he has not chosen any.
+func (storage *MemoryStorage) Language(userId int64) (string, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	return storage.languages[userId], nil
+}
+
+// SetLanguage remembers the language the user has chosen.
+func (storage *MemoryStorage) SetLanguage(userId int64, language string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	storage.languages[userId] = language
+	return nil
+}
+
+// Close does nothing, there is nothing to close.
+func (storage *MemoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*/*.sql
+var migrations embed.FS
+
+// Type migration is one versioned change of the schema.
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// loadMigrations reads all the migrations of the dialect embedded
+// in the binary sorted by their versions. The version is the number
+// the file name starts with, e.g. 0001_init.sql.
+func loadMigrations(dialect string) ([]migration, error) {
+	dir := "migrations/" + dialect + "/"
+
+	files, err := fs.Glob(migrations, dir+"*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations for %s", dialect)
+	}
+
+	var result []migration
+	for _, file := range files {
+		name := strings.TrimPrefix(file, dir)
+
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version: %w", name, err)
+		}
+
+		query, err := migrations.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, migration{version, name, string(query)})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].version < result[j].version
+	})
+
+	return result, nil
+}
+
+// Migrate applies all the migrations of the dialect ("postgres" or "sqlite")
+// that have not been applied yet. Every migration is applied in its own transaction.
+func Migrate(db *sql.DB, dialect string) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	all, err := loadMigrations(dialect)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		var applied bool
+		err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("check migration %s: %w", m.name, err)
+		}
+
+		if applied {
+			continue
+		}
+
+		if err := apply(db, m); err != nil {
+			return fmt.Errorf("apply migration %s: %w", m.name, err)
+		}
+
+		log.Printf("migration %s applied", m.name)
+	}
+
+	return nil
+}
+
+// apply runs the migration and remembers that it was applied.
+func apply(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.query); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
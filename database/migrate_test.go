@@ -0,0 +1,41 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	db, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	all, err := loadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []string{"empty database", "up-to-date database"}
+
+	for _, step := range steps {
+		if err := Migrate(db, "sqlite"); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+
+		var applied int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+			t.Fatal(err)
+		}
+		if applied != len(all) {
+			t.Errorf("%s: %d migrations are applied, want %d", step, applied, len(all))
+		}
+
+		// The last migration has added the column.
+		if _, err := db.Exec(`SELECT was_decided FROM game_session`); err != nil {
+			t.Errorf("%s: %v", step, err)
+		}
+	}
+}
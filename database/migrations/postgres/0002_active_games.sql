@@ -0,0 +1,7 @@
+-- Snapshots of the lobbies and games that are going on,
+-- they are restored when the bot starts.
+CREATE TABLE IF NOT EXISTS active_games (
+    id         BIGINT PRIMARY KEY,
+    state      TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- Elo ratings of the players: 'host' is the interrogator skill
+-- and 'knave' is the deception skill.
+CREATE TABLE IF NOT EXISTS ratings (
+    id_player BIGINT NOT NULL REFERENCES players (id),
+    role      TEXT NOT NULL REFERENCES roles (name),
+    rating    DOUBLE PRECISION NOT NULL,
+    games     INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (id_player, role)
+);
+
+CREATE INDEX IF NOT EXISTS ratings_role_rating_idx ON ratings (role, rating);
@@ -0,0 +1,3 @@
+-- Files attached to the messages, they are kept by telegram and only their ids are stored.
+ALTER TABLE messages ADD COLUMN media_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN file_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- Languages the users have chosen with /language, they are kept apart
+-- from the players, because users choose them before playing.
+CREATE TABLE IF NOT EXISTS user_languages (
+    id_player BIGINT PRIMARY KEY,
+    language  TEXT NOT NULL
+);
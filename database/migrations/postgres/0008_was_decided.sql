@@ -0,0 +1,4 @@
+-- Games that have a winner, the games somebody has left are finished, but not decided.
+-- Earlier only the guesses of the host were told apart, so forfeits are not decided there.
+ALTER TABLE game_session ADD COLUMN was_decided BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE game_session SET was_decided = was_succesfull;
@@ -0,0 +1,52 @@
+-- Players are stored once and referenced by every game they took part in.
+CREATE TABLE IF NOT EXISTS players (
+    id            INTEGER PRIMARY KEY,
+    first_name    TEXT NOT NULL DEFAULT '',
+    language_code TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY
+);
+
+INSERT INTO roles (name) VALUES ('host'), ('knave'), ('knight')
+ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS game_session (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    host_id        INTEGER NOT NULL REFERENCES players (id),
+    knight_id      INTEGER NOT NULL REFERENCES players (id),
+    knave_id       INTEGER NOT NULL REFERENCES players (id),
+    date_start     TEXT NOT NULL,
+    time_start     TEXT NOT NULL,
+    was_succesfull BOOLEAN NOT NULL DEFAULT FALSE,
+    was_finished   BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS game_session_host_id_idx ON game_session (host_id);
+CREATE INDEX IF NOT EXISTS game_session_knight_id_idx ON game_session (knight_id);
+CREATE INDEX IF NOT EXISTS game_session_knave_id_idx ON game_session (knave_id);
+CREATE INDEX IF NOT EXISTS game_session_date_start_idx ON game_session (date_start);
+
+-- Role and nickname every player had in the game.
+CREATE TABLE IF NOT EXISTS session_players (
+    id_session INTEGER NOT NULL REFERENCES game_session (id) ON DELETE CASCADE,
+    id_player  INTEGER NOT NULL REFERENCES players (id),
+    role       TEXT NOT NULL REFERENCES roles (name),
+    nickname   TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (id_session, id_player)
+);
+
+CREATE INDEX IF NOT EXISTS session_players_id_player_idx ON session_players (id_player);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_session      INTEGER NOT NULL REFERENCES game_session (id) ON DELETE CASCADE,
+    id_player       INTEGER NOT NULL REFERENCES players (id),
+    time_from_start INTEGER NOT NULL,
+    message         TEXT NOT NULL,
+    role            TEXT NOT NULL REFERENCES roles (name)
+);
+
+CREATE INDEX IF NOT EXISTS messages_id_session_idx ON messages (id_session);
+CREATE INDEX IF NOT EXISTS messages_id_player_idx ON messages (id_player);
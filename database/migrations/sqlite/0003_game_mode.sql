@@ -0,0 +1,6 @@
+-- Mode of the game: 'classic' or 'machine'. In the machine mode
+-- knave_id is the machine and knight_id is the human.
+ALTER TABLE game_session ADD COLUMN mode TEXT NOT NULL DEFAULT 'classic';
+
+INSERT INTO roles (name) VALUES ('machine'), ('human')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Result of the game, it is used for the statistics of the players.
+ALTER TABLE game_session ADD COLUMN host_won BOOLEAN NOT NULL DEFAULT FALSE;
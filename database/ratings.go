@@ -0,0 +1,148 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+const (
+	InitialRating = 1500.0 // InitialRating is the rating of the player who has not been rated yet.
+	ratingFactor  = 32.0   // ratingFactor is the biggest change of the rating after one game.
+)
+
+// Type RatingEntry is the line of the leaderboard.
+type RatingEntry struct {
+	User   gs.User
+	Rating float64
+	Games  int
+}
+
+// isRated checks if the game changes the ratings: the winner has to be
+// known and both the host and the knave have to be humans.
+func isRated(state *gs.GameState) bool {
+	return state.WasGameDecided && state.Mode == gs.ClassicMode &&
+		!state.Host.User.IsBot && !state.Knave.User.IsBot
+}
+
+// elo returns new ratings of the host and the knave after the game between them.
+func elo(host float64, knave float64, hostWon bool) (float64, float64) {
+	expected := 1 / (1 + math.Pow(10, (knave-host)/400))
+
+	score := 0.0
+	if hostWon {
+		score = 1
+	}
+
+	change := ratingFactor * (score - expected)
+	return host + change, knave - change
+}
+
+// ratingRole checks that the leaderboard is kept for the role.
+func ratingRole(role gs.PlayerRole) (string, error) {
+	if role != gs.Host && role != gs.Knave {
+		return "", fmt.Errorf("there is no rating for the role %d", role)
+	}
+
+	return roleName(role)
+}
+
+// updateRatings changes the ratings of the host and the knave
+// in the transaction the game is saved in.
+func updateRatings(tx *sql.Tx, state *gs.GameState) error {
+	if !isRated(state) {
+		return nil
+	}
+
+	host, err := rating(tx, state.Host.User.ID, "host")
+	if err != nil {
+		return err
+	}
+
+	knave, err := rating(tx, state.Knave.User.ID, "knave")
+	if err != nil {
+		return err
+	}
+
+	host, knave = elo(host, knave, state.HostWon)
+
+	if err := setRating(tx, state.Host.User.ID, "host", host); err != nil {
+		return err
+	}
+
+	return setRating(tx, state.Knave.User.ID, "knave", knave)
+}
+
+// rating returns the rating of the player in the role.
+func rating(tx *sql.Tx, playerId int64, role string) (float64, error) {
+	var value float64
+	err := tx.QueryRow(`SELECT rating FROM ratings WHERE id_player = $1 AND role = $2`, playerId, role).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return InitialRating, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("select rating: %w", err)
+	}
+
+	return value, nil
+}
+
+// setRating stores the new rating of the player in the role.
+func setRating(tx *sql.Tx, playerId int64, role string, value float64) error {
+	_, err := tx.Exec(
+		`INSERT INTO ratings (id_player, role, rating, games) VALUES ($1, $2, $3, 1)
+		ON CONFLICT (id_player, role) DO UPDATE SET rating = EXCLUDED.rating, games = ratings.games + 1`,
+		playerId, role, value,
+	)
+	if err != nil {
+		return fmt.Errorf("update rating: %w", err)
+	}
+
+	return nil
+}
+
+// Leaderboard returns the players with the best ratings in the role
+// starting from the offset and the number of all the rated players.
+func (storage *SQLStorage) Leaderboard(role gs.PlayerRole, offset int, limit int) ([]RatingEntry, int, error) {
+	name, err := ratingRole(role)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := storage.db.QueryRow(`SELECT COUNT(*) FROM ratings WHERE role = $1`, name).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count ratings: %w", err)
+	}
+
+	rows, err := storage.db.Query(
+		`SELECT p.id, p.first_name, p.language_code, r.rating, r.games
+		FROM ratings r JOIN players p ON p.id = r.id_player
+		WHERE r.role = $1
+		ORDER BY r.rating DESC, r.games DESC, p.id
+		LIMIT $2 OFFSET $3`,
+		name, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("select ratings: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []RatingEntry
+	for rows.Next() {
+		var entry RatingEntry
+		if err := rows.Scan(&entry.User.ID, &entry.User.FirstName, &entry.User.LanguageCode, &entry.Rating, &entry.Games); err != nil {
+			return nil, 0, fmt.Errorf("scan rating: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("select ratings: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,298 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// SQLStorage is the Storage that keeps games in Postgres or SQLite.
+// Both of them understand the same queries, only the schema differs.
+type SQLStorage struct {
+	db *sql.DB
+}
+
+// NewSQLStorage creates storage working through the connection.
+func NewSQLStorage(db *sql.DB) *SQLStorage {
+	return &SQLStorage{db}
+}
+
+// Close closes the connection to the database.
+func (storage *SQLStorage) Close() error {
+	return storage.db.Close()
+}
+
+// SaveGame stores the game session, messages of all its players and
+// their new ratings in one transaction, so either everything is saved or nothing.
+// The id the game is kept with is returned.
+func (storage *SQLStorage) SaveGame(state *gs.GameState) (int64, error) {
+	tx, err := storage.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	players := []*gs.Player{state.Host, state.Knave, state.Knight}
+	for _, player := range players {
+		if err := addPlayer(tx, player); err != nil {
+			return 0, err
+		}
+	}
+
+	// The columns keep no time zone, so the start is kept in UTC
+	// and read back as UTC.
+	start := state.BegginingDate.UTC()
+	date := start.Format("2006-01-02")
+	clock := start.Format("15:04:05")
+
+	mode, err := modeName(state.Mode)
+	if err != nil {
+		return 0, err
+	}
+
+	var idSession int64
+	err = tx.QueryRow(
+		`INSERT INTO game_session (host_id, knight_id, knave_id, date_start, time_start, was_succesfull, was_finished, was_decided, mode, host_won)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		state.Host.User.ID, state.Knight.User.ID, state.Knave.User.ID, date, clock,
+		state.WasGameSuccesfull, state.WasGameFinished, state.WasGameDecided, mode, state.HostWon,
+	).Scan(&idSession)
+	if err != nil {
+		return 0, fmt.Errorf("insert game session: %w", err)
+	}
+
+	for _, player := range players {
+		if err := addSessionPlayer(tx, player, idSession); err != nil {
+			return 0, err
+		}
+
+		if err := addMessages(tx, player, idSession); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := updateRatings(tx, state); err != nil {
+		return 0, err
+	}
+
+	return idSession, tx.Commit()
+}
+
+// addPlayer stores the player or updates his name and language
+// if he has already played.
+func addPlayer(tx *sql.Tx, player *gs.Player) error {
+	_, err := tx.Exec(
+		`INSERT INTO players (id, first_name, language_code) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET first_name = EXCLUDED.first_name, language_code = EXCLUDED.language_code`,
+		player.User.ID, player.User.FirstName, player.User.LanguageCode,
+	)
+	if err != nil {
+		return fmt.Errorf("insert player: %w", err)
+	}
+
+	return nil
+}
+
+// addSessionPlayer stores the role and nickname the player had in the game.
+func addSessionPlayer(tx *sql.Tx, player *gs.Player, idSession int64) error {
+	role, err := roleName(player.Role)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO session_players (id_session, id_player, role, nickname) VALUES ($1, $2, $3, $4)`,
+		idSession, player.User.ID, role, player.NickName,
+	)
+	if err != nil {
+		return fmt.Errorf("insert session player: %w", err)
+	}
+
+	return nil
+}
+
+// addMessages stores the history of the player.
+func addMessages(tx *sql.Tx, player *gs.Player, idSession int64) error {
+	role, err := roleName(player.Role)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range player.History {
+		_, err := tx.Exec(
+			`INSERT INTO messages (id_session, id_player, time_from_start, message, role, media_type, file_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			idSession, player.User.ID, message.TimeFromTheBeg, message.Message, role, string(message.MediaType), message.FileID,
+		)
+		if err != nil {
+			return fmt.Errorf("insert message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// roleName returns the name of the role that is kept in the database.
+func roleName(role gs.PlayerRole) (string, error) {
+	switch role {
+	case gs.Host:
+		return "host", nil
+	case gs.Knave:
+		return "knave", nil
+	case gs.Knight:
+		return "knight", nil
+	case gs.Machine:
+		return "machine", nil
+	case gs.Human:
+		return "human", nil
+	}
+
+	return "", fmt.Errorf("player with role %d cannot be saved", role)
+}
+
+// modeName returns the name of the mode that is kept in the database.
+func modeName(mode gs.GameMode) (string, error) {
+	switch mode {
+	case gs.ClassicMode:
+		return "classic", nil
+	case gs.MachineMode:
+		return "machine", nil
+	}
+
+	return "", fmt.Errorf("game with mode %d cannot be saved", mode)
+}
+
+// SaveSnapshot stores the snapshot replacing the previous one of the same game.
+func (storage *SQLStorage) SaveSnapshot(snapshot gs.Snapshot) error {
+	state, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.db.Exec(
+		`INSERT INTO active_games (id, state, updated_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at`,
+		snapshot.Id, string(state),
+	)
+	if err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSnapshot forgets the game that is over.
+func (storage *SQLStorage) DeleteSnapshot(id int64) error {
+	if _, err := storage.db.Exec(`DELETE FROM active_games WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshots returns all the stored snapshots.
+func (storage *SQLStorage) LoadSnapshots() ([]gs.Snapshot, error) {
+	rows, err := storage.db.Query(`SELECT state FROM active_games ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("load snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []gs.Snapshot
+	for rows.Next() {
+		var state string
+		if err := rows.Scan(&state); err != nil {
+			return nil, err
+		}
+
+		var snapshot gs.Snapshot
+		if err := json.Unmarshal([]byte(state), &snapshot); err != nil {
+			return nil, fmt.Errorf("decode snapshot: %w", err)
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// PlayerStats returns the statistics of the user, it is empty
+// if the user has not played yet.
+func (storage *SQLStorage) PlayerStats(userId int64) (PlayerStats, error) {
+	stats := PlayerStats{Roles: make(map[gs.PlayerRole]RoleStats)}
+
+	rows, err := storage.db.Query(
+		`SELECT sp.role, COUNT(*),
+			SUM(CASE WHEN g.was_decided THEN 1 ELSE 0 END),
+			SUM(CASE WHEN g.was_decided AND g.host_won THEN 1 ELSE 0 END)
+		FROM session_players sp JOIN game_session g ON g.id = sp.id_session
+		WHERE sp.id_player = $1
+		GROUP BY sp.role`,
+		userId,
+	)
+	if err != nil {
+		return stats, fmt.Errorf("select games: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var games, decided, hostWon int
+		if err := rows.Scan(&name, &games, &decided, &hostWon); err != nil {
+			return stats, fmt.Errorf("scan games: %w", err)
+		}
+
+		role, isKnown := roleByName(name)
+		if !isKnown {
+			continue
+		}
+
+		wins := hostWon
+		if !winsWithHost(role) {
+			wins = decided - hostWon
+		}
+
+		stats.Roles[role] = RoleStats{Games: games, Decided: decided, Wins: wins}
+	}
+
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("select games: %w", err)
+	}
+
+	// Questions are counted only in the games where the host has made his guess.
+	var questions sql.NullFloat64
+	err = storage.db.QueryRow(
+		`SELECT AVG(q.number * 1.0) FROM (
+			SELECT COUNT(m.id) AS number
+			FROM game_session g LEFT JOIN messages m ON m.id_session = g.id AND m.id_player = g.host_id
+			WHERE g.host_id = $1 AND g.was_succesfull
+			GROUP BY g.id
+		) q`,
+		userId,
+	).Scan(&questions)
+	if err != nil {
+		return stats, fmt.Errorf("select questions: %w", err)
+	}
+
+	// Response time is the time from the last question of the host to the answer.
+	var response sql.NullFloat64
+	err = storage.db.QueryRow(
+		`SELECT AVG((m.time_from_start - (
+			SELECT MAX(h.time_from_start) FROM messages h
+			WHERE h.id_session = m.id_session AND h.role = 'host' AND h.time_from_start <= m.time_from_start
+		)) * 1.0)
+		FROM messages m
+		WHERE m.id_player = $1 AND m.role <> 'host'`,
+		userId,
+	).Scan(&response)
+	if err != nil {
+		return stats, fmt.Errorf("select response time: %w", err)
+	}
+
+	stats.AverageQuestions = questions.Float64
+	stats.AverageResponse = time.Duration(response.Float64 * float64(time.Second))
+
+	return stats, nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// newSQLite creates the storage in the in-memory SQLite database
+// with the schema brought up to date.
+func newSQLite(t *testing.T) *SQLStorage {
+	t.Helper()
+
+	// The only connection keeps the in-memory database alive.
+	storage, err := openSQL("sqlite", "sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	return storage
+}
+
+// newFinishedGame creates the classic game of the users in which
+// the host has asked one question and has guessed right.
+func newFinishedGame(host, knave, knight gs.User) *gs.GameState {
+	state := &gs.GameState{
+		Mode:              gs.ClassicMode,
+		WasGameSuccesfull: true,
+		WasGameFinished:   true,
+		WasGameDecided:    true,
+		HostWon:           true,
+		BegginingDate:     time.Date(2023, time.March, 8, 21, 30, 15, 0, time.FixedZone("MSK", 3*60*60)),
+	}
+
+	state.Host = &gs.Player{User: &host, Role: gs.Host, State: state,
+		History: []gs.MessageHistory{{Message: "Who's there? '); DROP TABLE players; --", TimeFromTheBeg: 5}}}
+	state.Knave = &gs.Player{User: &knave, Role: gs.Knave, NickName: "Fox", State: state,
+		History: []gs.MessageHistory{{Message: `It's "me"`, TimeFromTheBeg: 12}}}
+	state.Knight = &gs.Player{User: &knight, Role: gs.Knight, NickName: "Owl", State: state,
+		History: []gs.MessageHistory{{Message: "Me", TimeFromTheBeg: 20, MediaType: gs.Photo, FileID: "photo"}}}
+
+	state.Players = []*gs.Player{state.Host, state.Knave, state.Knight}
+	state.RightPlayer = state.Knight
+
+	return state
+}
+
+// count returns the number of the rows the query selects.
+func count(t *testing.T, storage *SQLStorage, query string, args ...interface{}) int {
+	t.Helper()
+
+	var n int
+	if err := storage.db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestSaveGame(t *testing.T) {
+	host := gs.User{ID: 1, FirstName: "Hanna", LanguageCode: "en"}
+	knave := gs.User{ID: 2, FirstName: "Kevin", LanguageCode: "ru"}
+	knight := gs.User{ID: 3, FirstName: "Nina", LanguageCode: "en"}
+
+	tests := []struct {
+		name         string
+		prepare      func(state *gs.GameState)
+		wantErr      bool
+		wantSessions int
+		wantMessages int
+	}{
+		{"game is saved", nil, false, 1, 3},
+		{"nothing is saved if a player cannot be", func(state *gs.GameState) {
+			state.Knight.Role = gs.PlayerRole(100)
+		}, true, 0, 0},
+		{"nothing is saved if the mode cannot be", func(state *gs.GameState) {
+			state.Mode = gs.GameMode(100)
+		}, true, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := newSQLite(t)
+
+			state := newFinishedGame(host, knave, knight)
+			if test.prepare != nil {
+				test.prepare(state)
+			}
+
+			id, err := storage.SaveGame(state)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("SaveGame() error = %v, want error: %t", err, test.wantErr)
+			}
+
+			if n := count(t, storage, `SELECT COUNT(*) FROM game_session`); n != test.wantSessions {
+				t.Errorf("%d game sessions are saved, want %d", n, test.wantSessions)
+			}
+			if n := count(t, storage, `SELECT COUNT(*) FROM messages`); n != test.wantMessages {
+				t.Errorf("%d messages are saved, want %d", n, test.wantMessages)
+			}
+			if test.wantErr {
+				if n := count(t, storage, `SELECT COUNT(*) FROM players`); n != 0 {
+					t.Errorf("%d players are saved with the failed game", n)
+				}
+				return
+			}
+
+			var knightId, knaveId int64
+			err = storage.db.QueryRow(`SELECT knight_id, knave_id FROM game_session WHERE id = $1`, id).Scan(&knightId, &knaveId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if knightId != knight.ID || knaveId != knave.ID {
+				t.Errorf("knight %d and knave %d are saved, want %d and %d", knightId, knaveId, knight.ID, knave.ID)
+			}
+
+			// The quotes are kept as they are.
+			for _, player := range state.Players {
+				text := player.History[0].Message
+				if n := count(t, storage, `SELECT COUNT(*) FROM messages WHERE id_player = $1 AND message = $2`, player.User.ID, text); n != 1 {
+					t.Errorf("message %q of user %d is not saved", text, player.User.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestAddPlayer(t *testing.T) {
+	storage := newSQLite(t)
+
+	steps := []struct {
+		name     string
+		user     gs.User
+		wantName string
+		wantCode string
+	}{
+		{"new player", gs.User{ID: 1, FirstName: "Hanna", LanguageCode: "en"}, "Hanna", "en"},
+		{"player has renamed himself", gs.User{ID: 1, FirstName: "Anna", LanguageCode: "ru"}, "Anna", "ru"},
+		{"name with a quote", gs.User{ID: 1, FirstName: "O'Hara", LanguageCode: "en"}, "O'Hara", "en"},
+	}
+
+	for _, step := range steps {
+		tx, err := storage.db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		user := step.user
+		if err := addPlayer(tx, &gs.Player{User: &user}); err != nil {
+			tx.Rollback()
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		var name, code string
+		err = storage.db.QueryRow(`SELECT first_name, language_code FROM players WHERE id = $1`, user.ID).Scan(&name, &code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != step.wantName || code != step.wantCode {
+			t.Errorf("%s: player is %q, %q, want %q, %q", step.name, name, code, step.wantName, step.wantCode)
+		}
+		if n := count(t, storage, `SELECT COUNT(*) FROM players`); n != 1 {
+			t.Errorf("%s: %d players are stored, want 1", step.name, n)
+		}
+	}
+}
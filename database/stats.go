@@ -0,0 +1,57 @@
+package database
+
+import (
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// Type RoleStats contains the results of the player in one of the roles.
+type RoleStats struct {
+	Games   int // Games is the number of the games the player had this role in.
+	Decided int // Decided is the number of those games that have been finished with a winner.
+	Wins    int
+}
+
+// WinRate returns the part of the decided games the player has won.
+func (stats RoleStats) WinRate() float64 {
+	if stats.Decided == 0 {
+		return 0
+	}
+
+	return float64(stats.Wins) / float64(stats.Decided)
+}
+
+// Type PlayerStats contains everything about the games the player has played.
+type PlayerStats struct {
+	Roles map[gs.PlayerRole]RoleStats
+
+	AverageQuestions float64       // AverageQuestions is how many questions the player asks as the host before the guess.
+	AverageResponse  time.Duration // AverageResponse is how long the player answers the questions of the host.
+}
+
+// Games returns the number of all the games of the player.
+func (stats PlayerStats) Games() int {
+	games := 0
+	for _, role := range stats.Roles {
+		games += role.Games
+	}
+
+	return games
+}
+
+// winsWithHost checks if the player of the role wins when the host wins.
+func winsWithHost(role gs.PlayerRole) bool {
+	return role == gs.Host || role == gs.Knight || role == gs.Human
+}
+
+// roleByName returns the role with the name that is kept in the database.
+func roleByName(name string) (gs.PlayerRole, bool) {
+	for _, role := range []gs.PlayerRole{gs.Host, gs.Knave, gs.Knight, gs.Machine, gs.Human} {
+		if roleName, _ := roleName(role); roleName == name {
+			return role, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	gs "github.com/dzendos/Turing/game"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// GameRepository stores the games that have been played.
+type GameRepository interface {
+	// SaveGame stores the game with all the messages of its players
+	// and returns the id the game is kept with.
+	SaveGame(state *gs.GameState) (int64, error)
+}
+
+// SnapshotRepository stores the games that are going on,
+// so they can be continued after the bot restarts.
+type SnapshotRepository interface {
+	// SaveSnapshot stores the snapshot replacing the previous one of the same game.
+	SaveSnapshot(snapshot gs.Snapshot) error
+	// DeleteSnapshot forgets the game that is over.
+	DeleteSnapshot(id int64) error
+	// LoadSnapshots returns all the stored snapshots.
+	LoadSnapshots() ([]gs.Snapshot, error)
+}
+
+// StatsRepository collects the statistics from the games that have been played.
+type StatsRepository interface {
+	// PlayerStats returns the statistics of the user, it is empty
+	// if the user has not played yet.
+	PlayerStats(userId int64) (PlayerStats, error)
+}
+
+// RatingRepository keeps the ratings that are updated with every game.
+type RatingRepository interface {
+	// Leaderboard returns the players with the best ratings in the role
+	// starting from the offset and the number of all the rated players.
+	Leaderboard(role gs.PlayerRole, offset int, limit int) ([]RatingEntry, int, error)
+}
+
+// TranscriptRepository restores the dialogues of the games that have been played.
+type TranscriptRepository interface {
+	// Transcript returns the transcript of the stored game,
+	// ErrNotFound is returned if there is no such game.
+	Transcript(id int64) (gs.Transcript, error)
+}
+
+// SessionRepository exports the games that have been played.
+type SessionRepository interface {
+	// Sessions returns all the finished games that match the filter.
+	Sessions(filter SessionFilter) ([]Session, error)
+}
+
+// LanguageRepository remembers the languages the users have chosen.
+type LanguageRepository interface {
+	// Language returns the language the user has chosen,
+	// it is empty if he has not chosen any.
+	Language(userId int64) (string, error)
+	// SetLanguage remembers the language the user has chosen.
+	SetLanguage(userId int64, language string) error
+}
+
+// Storage keeps everything the bot has to remember.
+type Storage interface {
+	GameRepository
+	SnapshotRepository
+	StatsRepository
+	RatingRepository
+	TranscriptRepository
+	SessionRepository
+	LanguageRepository
+
+	Close() error
+}
+
+// Open creates the storage chosen in the configuration.
+// Postgres is used when no driver is specified.
+func Open(config Config) (Storage, error) {
+	switch config.Driver {
+	case "", "postgres":
+		URL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", config.User, config.Password, config.Host, config.Port, config.Name)
+		return openSQL("postgres", "postgres", URL)
+	case "sqlite":
+		if config.Path == "" {
+			return nil, fmt.Errorf("path to the sqlite database is not specified")
+		}
+
+		return openSQL("sqlite", "sqlite", "file:"+config.Path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	case "memory":
+		return NewMemoryStorage(), nil
+	}
+
+	return nil, fmt.Errorf("unknown database driver %q", config.Driver)
+}
+
+// openSQL connects to the database and brings its schema
+// up to date with the migrations.
+func openSQL(driver, dialect, source string) (*SQLStorage, error) {
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite does not allow several writers at a time.
+	if dialect == "sqlite" {
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := Migrate(db, dialect); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewSQLStorage(db), nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// storages returns every backend the same behaviour is expected from.
+func storages(t *testing.T) map[string]Storage {
+	return map[string]Storage{
+		"memory": NewMemoryStorage(),
+		"sqlite": newSQLite(t),
+	}
+}
+
+func TestStorage(t *testing.T) {
+	host := gs.User{ID: 1, FirstName: "Hanna", LanguageCode: "en"}
+	knave := gs.User{ID: 2, FirstName: "Kevin", LanguageCode: "ru"}
+	knight := gs.User{ID: 3, FirstName: "Nina", LanguageCode: "en"}
+
+	var gameId int64
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T, storage Storage)
+	}{
+		{"user has no language", func(t *testing.T, storage Storage) {
+			if language, err := storage.Language(host.ID); err != nil || language != "" {
+				t.Errorf("Language() = %q, %v, want nothing", language, err)
+			}
+		}},
+		{"language is chosen twice", func(t *testing.T, storage Storage) {
+			for _, language := range []string{"ru", "en"} {
+				if err := storage.SetLanguage(host.ID, language); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if language, err := storage.Language(host.ID); err != nil || language != "en" {
+				t.Errorf("Language() = %q, %v, want %q", language, err, "en")
+			}
+		}},
+		{"game is saved", func(t *testing.T, storage Storage) {
+			id, err := storage.SaveGame(newFinishedGame(host, knave, knight))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gameId = id
+		}},
+		{"stats of the host", func(t *testing.T, storage Storage) {
+			stats, err := storage.PlayerStats(host.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role := stats.Roles[gs.Host]; role != (RoleStats{Games: 1, Decided: 1, Wins: 1}) || stats.Games() != 1 {
+				t.Errorf("host stats = %+v, %d games", role, stats.Games())
+			}
+			if stats.AverageQuestions != 1 {
+				t.Errorf("AverageQuestions = %v, want 1", stats.AverageQuestions)
+			}
+		}},
+		{"stats of the knave", func(t *testing.T, storage Storage) {
+			stats, err := storage.PlayerStats(knave.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role := stats.Roles[gs.Knave]; role != (RoleStats{Games: 1, Decided: 1, Wins: 0}) || stats.Games() != 1 {
+				t.Errorf("knave stats = %+v, %d games", role, stats.Games())
+			}
+		}},
+		{"stats of the stranger", func(t *testing.T, storage Storage) {
+			stats, err := storage.PlayerStats(100)
+			if err != nil || stats.Games() != 0 {
+				t.Errorf("PlayerStats() = %+v, %v, want nothing", stats, err)
+			}
+		}},
+		{"leaderboards", func(t *testing.T, storage Storage) {
+			hosts, total, err := storage.Leaderboard(gs.Host, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || len(hosts) != 1 || hosts[0].User.ID != host.ID || hosts[0].Rating <= InitialRating || hosts[0].Games != 1 {
+				t.Errorf("hosts = %+v, %d in total", hosts, total)
+			}
+
+			knaves, total, err := storage.Leaderboard(gs.Knave, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || len(knaves) != 1 || knaves[0].User.ID != knave.ID || knaves[0].Rating >= InitialRating {
+				t.Errorf("knaves = %+v, %d in total", knaves, total)
+			}
+
+			if rest, _, err := storage.Leaderboard(gs.Host, 1, 10); err != nil || len(rest) != 0 {
+				t.Errorf("page after the last one = %+v, %v", rest, err)
+			}
+		}},
+		{"game somebody has left is not rated", func(t *testing.T, storage Storage) {
+			before, _, err := storage.Leaderboard(gs.Knave, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			walkout := newFinishedGame(host, knave, knight)
+			walkout.WasGameDecided, walkout.HostWon = false, false
+			if _, err := storage.SaveGame(walkout); err != nil {
+				t.Fatal(err)
+			}
+
+			after, _, err := storage.Leaderboard(gs.Knave, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(after) != 1 || after[0] != before[0] {
+				t.Errorf("knaves = %+v after the walkout, want %+v", after, before)
+			}
+
+			stats, err := storage.PlayerStats(knave.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role := stats.Roles[gs.Knave]; role != (RoleStats{Games: 2, Decided: 1, Wins: 0}) {
+				t.Errorf("knave stats = %+v after the walkout", role)
+			}
+		}},
+		{"transcript", func(t *testing.T, storage Storage) {
+			transcript, err := storage.Transcript(gameId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !transcript.Decided || !transcript.HostWon || transcript.Mode != "classic" {
+				t.Errorf("transcript = %+v", transcript)
+			}
+			if start := newFinishedGame(host, knave, knight).BegginingDate; !transcript.Start.Equal(start) || transcript.Start.Location() != time.UTC {
+				t.Errorf("game has started at %v, want %v in UTC", transcript.Start, start)
+			}
+			if len(transcript.Players) != 3 || transcript.Players[0].Name != host.FirstName {
+				t.Errorf("players = %+v, the host is not the first", transcript.Players)
+			}
+			if len(transcript.Messages) != 3 || transcript.Messages[0].Role != gs.Host.String() {
+				t.Errorf("messages = %+v", transcript.Messages)
+			}
+		}},
+		{"transcript of the unknown game", func(t *testing.T, storage Storage) {
+			if _, err := storage.Transcript(gameId + 100); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Transcript() error = %v, want ErrNotFound", err)
+			}
+		}},
+		{"snapshot is replaced", func(t *testing.T, storage Storage) {
+			for round := 1; round <= 2; round++ {
+				if err := storage.SaveSnapshot(gs.Snapshot{Id: 7, Round: round}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			snapshots, err := storage.LoadSnapshots()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != 1 || snapshots[0].Id != 7 || snapshots[0].Round != 2 {
+				t.Errorf("snapshots = %+v", snapshots)
+			}
+		}},
+		{"snapshot is deleted", func(t *testing.T, storage Storage) {
+			if err := storage.DeleteSnapshot(7); err != nil {
+				t.Fatal(err)
+			}
+
+			if snapshots, err := storage.LoadSnapshots(); err != nil || len(snapshots) != 0 {
+				t.Errorf("snapshots = %+v, %v, want none", snapshots, err)
+			}
+		}},
+	}
+
+	for name, storage := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, step := range steps {
+				t.Run(step.name, func(t *testing.T) {
+					step.run(t, storage)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	gs "github.com/dzendos/Turing/game"
+)
+
+// ErrNotFound is returned when there is nothing stored with the given id.
+var ErrNotFound = errors.New("not found")
+
+// sortPlayers puts the host of the transcript first.
+func sortPlayers(players []gs.TranscriptPlayer) {
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Role == gs.Host.String() && players[j].Role != gs.Host.String()
+	})
+}
+
+// Transcript returns the transcript of the stored game.
+func (storage *SQLStorage) Transcript(id int64) (gs.Transcript, error) {
+	transcript := gs.Transcript{GameId: id}
+
+	var date, clock string
+	err := storage.db.QueryRow(
+		`SELECT CAST(date_start AS TEXT), CAST(time_start AS TEXT), was_decided, host_won, mode
+		FROM game_session WHERE id = $1`,
+		id,
+	).Scan(&date, &clock, &transcript.Decided, &transcript.HostWon, &transcript.Mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return transcript, ErrNotFound
+	}
+	if err != nil {
+		return transcript, fmt.Errorf("select game session: %w", err)
+	}
+
+	transcript.Start, err = time.Parse("2006-01-02 15:04:05", date+" "+clock)
+	if err != nil {
+		return transcript, fmt.Errorf("parse start of the game: %w", err)
+	}
+
+	rows, err := storage.db.Query(
+		`SELECT sp.id_player, p.first_name, sp.nickname, sp.role
+		FROM session_players sp JOIN players p ON p.id = sp.id_player
+		WHERE sp.id_session = $1
+		ORDER BY sp.role`,
+		id,
+	)
+	if err != nil {
+		return transcript, fmt.Errorf("select session players: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var player gs.TranscriptPlayer
+		if err := rows.Scan(&player.UserID, &player.Name, &player.NickName, &player.Role); err != nil {
+			return transcript, fmt.Errorf("scan session player: %w", err)
+		}
+
+		// Bots are the only players with negative ids.
+		player.IsBot = player.UserID < 0
+		transcript.Players = append(transcript.Players, player)
+	}
+
+	if err := rows.Err(); err != nil {
+		return transcript, fmt.Errorf("select session players: %w", err)
+	}
+
+	sortPlayers(transcript.Players)
+
+	messages, err := storage.db.Query(
+		`SELECT id_player, time_from_start, message, media_type, file_id FROM messages WHERE id_session = $1 ORDER BY id`,
+		id,
+	)
+	if err != nil {
+		return transcript, fmt.Errorf("select messages: %w", err)
+	}
+	defer messages.Close()
+
+	histories := make(map[int64][]gs.MessageHistory)
+	for messages.Next() {
+		var playerId int64
+		var message gs.MessageHistory
+		var mediaType string
+		if err := messages.Scan(&playerId, &message.TimeFromTheBeg, &message.Message, &mediaType, &message.FileID); err != nil {
+			return transcript, fmt.Errorf("scan message: %w", err)
+		}
+		message.MediaType = gs.MediaType(mediaType)
+
+		histories[playerId] = append(histories[playerId], message)
+	}
+
+	if err := messages.Err(); err != nil {
+		return transcript, fmt.Errorf("select messages: %w", err)
+	}
+
+	return gs.NewTranscript(transcript, histories), nil
+}
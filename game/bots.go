@@ -0,0 +1,158 @@
+package game
+
+import (
+	"context"
+	"math/rand"
+)
+
+// Type PlayerBackend plays the knave or the knight instead of a human:
+// it answers the questions of the host.
+type PlayerBackend interface {
+	Answer(ctx context.Context, prompt Prompt) (string, error)
+}
+
+// Type Prompt contains everything the bot player knows
+// when the host is waiting for his answer.
+type Prompt struct {
+	GameId   int64
+	UserID   int64 // UserID is the id of the bot player that has to answer.
+	Role     PlayerRole
+	Name     string // Name is the name of the bot player the host sees among the names of the players.
+	Imitate  string // Imitate is the name of the other player, the knave pretends to be him.
+	Language string
+
+	Dialogue []Exchange // Dialogue contains all the rounds, the answer for the last one is empty.
+}
+
+// Type Exchange is the question of the host and the answer of the bot player.
+type Exchange struct {
+	Question string
+	Answer   string
+}
+
+// AddBotEvent - creator of the lobby wants a bot player to fill the empty slot.
+type AddBotEvent struct {
+	UserID int64
+}
+
+func (AddBotEvent) isEvent() {}
+
+// botNames are the names bot players introduce themselves with.
+var botNames = []string{"Alan", "Ada", "Grace", "Kurt", "Emmy", "Nikola", "Marie", "John"}
+
+// botAdded puts a new bot player in the lobby.
+func (gs *GameState) botAdded(id int64) []Notification {
+	player := gs.Player(id)
+	if player == nil {
+		return nil
+	}
+
+	if id != gs.HostId {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "NotACreator"))}
+	}
+
+	if gs.HasStarted() || gs.IsGameOver {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "UserAlreadyInGame"))}
+	}
+
+	// The machine is the only bot in the game of the MachineMode.
+	if gs.Mode == MachineMode {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "MachineAlreadyInGame"))}
+	}
+
+	return gs.playerJoined(gs.newBot(player.User.LanguageCode))
+}
+
+// newBot creates the bot user that is not in the game yet.
+func (gs *GameState) newBot(language string) *User {
+	name := gs.botName()
+
+	// Bots get negative ids, so they never meet real users.
+	// The id depends on the name only, so the bot is stored
+	// as the same player in all the games.
+	return &User{
+		ID:           -int64(name + 1),
+		FirstName:    botNames[name],
+		LanguageCode: language,
+		IsBot:        true,
+	}
+}
+
+// botName chooses the name nobody in the lobby has,
+// its index in botNames is returned.
+func (gs *GameState) botName() int {
+	var free []int
+	for i, name := range botNames {
+		isTaken := false
+		for _, player := range gs.Players {
+			if player.User.FirstName == name {
+				isTaken = true
+			}
+		}
+
+		if !isTaken {
+			free = append(free, i)
+		}
+	}
+
+	// The lobby has three players at most, so some names are always free.
+	return free[rand.Intn(len(free))]
+}
+
+// humans returns the number of players that are not bots.
+func (gs *GameState) humans() int {
+	number := 0
+	for _, player := range gs.Players {
+		if !player.User.IsBot {
+			number++
+		}
+	}
+
+	return number
+}
+
+// prompts asks the bot players the game is waiting for to answer.
+func (gs *GameState) prompts() []Notification {
+	if !gs.HasStarted() || gs.IsGameOver || gs.IsHostTurn || gs.IsDecisionTime {
+		return nil
+	}
+
+	var notifications []Notification
+	for _, player := range gs.idlePlayers() {
+		if player.User.IsBot {
+			prompt := gs.prompt(player)
+			notifications = append(notifications, Notification{To: player.User, Prompt: &prompt})
+		}
+	}
+
+	return notifications
+}
+
+// prompt collects the dialogue between the host and the bot player.
+func (gs *GameState) prompt(player *Player) Prompt {
+	other := gs.Knight
+	if player == gs.Knight {
+		other = gs.Knave
+	}
+
+	prompt := Prompt{
+		GameId:   gs.Id,
+		UserID:   player.User.ID,
+		Role:     player.Role,
+		Name:     player.User.FirstName,
+		Imitate:  other.User.FirstName,
+		Language: player.User.LanguageCode,
+	}
+
+	// Every round has one question of the host and one answer of the player.
+	for i, question := range gs.Host.History {
+		exchange := Exchange{Question: question.describe()}
+		if i < len(player.History) {
+			exchange.Answer = player.History[i].describe()
+		}
+
+		prompt.Dialogue = append(prompt.Dialogue, exchange)
+	}
+
+	return prompt
+}
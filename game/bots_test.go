@@ -0,0 +1,29 @@
+package game
+
+import "testing"
+
+func TestBotIds(t *testing.T) {
+	ids := make(map[string]int64)
+	names := make(map[int64]string)
+
+	// Names of the bots are random, so there are enough games
+	// for most of the names to appear.
+	for i := 0; i < 50; i++ {
+		host, _, _ := testUsers()
+		gs := NewMachineGameState(host, local(t), DefaultSettings())
+		bot := gs.Players[1].User
+
+		if bot.ID >= 0 || !bot.IsBot {
+			t.Fatalf("bot %s has id %d", bot.FirstName, bot.ID)
+		}
+
+		if id, isKnown := ids[bot.FirstName]; isKnown && id != bot.ID {
+			t.Errorf("bot %s has ids %d and %d", bot.FirstName, id, bot.ID)
+		}
+		if name, isKnown := names[bot.ID]; isKnown && name != bot.FirstName {
+			t.Errorf("bots %s and %s have the same id %d", name, bot.FirstName, bot.ID)
+		}
+
+		ids[bot.FirstName], names[bot.ID] = bot.ID, bot.FirstName
+	}
+}
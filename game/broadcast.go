@@ -0,0 +1,46 @@
+package game
+
+import lcl "github.com/dzendos/Turing/config/locales"
+
+// broadcast creates the notifications for everyone who follows the game
+// without playing it: the group chat the game is bound to and the spectators.
+// The text is created for the language of every audience.
+func (gs *GameState) broadcast(text func(language string) string) []Notification {
+	var notifications []Notification
+	if gs.ChatId != 0 {
+		notifications = append(notifications, Notification{Chat: gs.ChatId, Text: text(gs.ChatLanguage)})
+	}
+
+	return append(notifications, gs.spectate(text)...)
+}
+
+// spectate creates the notifications only for the spectators, e.g. for
+// the message the group chat has already seen.
+func (gs *GameState) spectate(text func(language string) string) []Notification {
+	var notifications []Notification
+	for _, spectator := range gs.Spectators {
+		notifications = append(notifications, notify(spectator, text(spectator.LanguageCode)))
+	}
+
+	return notifications
+}
+
+// broadcastKey broadcasts the message with the key in the localizer.
+func (gs *GameState) broadcastKey(key string) []Notification {
+	return gs.broadcast(func(language string) string {
+		return gs.local.Get(language, key)
+	})
+}
+
+// reveal tells the audience who was hiding behind the nicknames.
+func (gs *GameState) reveal() []Notification {
+	knaveKey, knightKey := lcl.Key("RevealKnave"), lcl.Key("RevealKnight")
+	if gs.Mode == MachineMode {
+		knaveKey, knightKey = lcl.Key("RevealMachine"), lcl.Key("RevealHuman")
+	}
+
+	return gs.broadcast(func(language string) string {
+		return gs.local.Format(language, knaveKey, lcl.Params{"nickname": gs.Knave.NickName, "name": gs.Knave.User.FirstName}) + "\n" +
+			gs.local.Format(language, knightKey, lcl.Params{"nickname": gs.Knight.NickName, "name": gs.Knight.User.FirstName})
+	})
+}
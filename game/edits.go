@@ -0,0 +1,122 @@
+package game
+
+import lcl "github.com/dzendos/Turing/config/locales"
+
+// Type EditPolicy decides what happens when a player edits
+// the message that has already been sent to others.
+type EditPolicy int
+
+const (
+	RejectEdits  EditPolicy = iota // RejectEdits keeps the first version of the message, the player is told about it.
+	ForwardEdits                   // ForwardEdits sends the new version of the message to others as edited.
+)
+
+// ParseEditPolicy returns the policy with the given name: reject or forward.
+func ParseEditPolicy(name string) (EditPolicy, bool) {
+	switch name {
+	case "reject":
+		return RejectEdits, true
+	case "forward":
+		return ForwardEdits, true
+	}
+
+	return RejectEdits, false
+}
+
+// EditEvent - player has edited the message he sent during the game.
+type EditEvent struct {
+	UserID    int64
+	MessageID int
+	Text      string
+}
+
+// EditsEvent - creator of the lobby decides what to do with edited messages.
+type EditsEvent struct {
+	UserID int64
+	Policy EditPolicy
+}
+
+func (EditEvent) isEvent()  {}
+func (EditsEvent) isEvent() {}
+
+// messageEdited applies the policy of the game to the edited message.
+// Messages that are not in the history of the player are not the part of the game.
+func (gs *GameState) messageEdited(id int64, messageId int, text string) []Notification {
+	player := gs.Player(id)
+	if player == nil || messageId == 0 || !gs.HasStarted() || gs.IsGameOver {
+		return nil
+	}
+
+	var message *MessageHistory
+	for i := range player.History {
+		if player.History[i].MessageID == messageId {
+			message = &player.History[i]
+		}
+	}
+
+	if message == nil || message.Message == text {
+		return nil
+	}
+
+	if gs.Settings.Edits == RejectEdits {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "EditRejected"))}
+	}
+
+	message.Revisions = append(message.Revisions, message.Message)
+	message.Message = text
+
+	var notifications []Notification
+	if player == gs.Host {
+		for _, respondent := range []*Player{gs.Knave, gs.Knight} {
+			notifications = append(notifications, notify(respondent.User, gs.edited(respondent.User.LanguageCode, "", text)))
+		}
+
+		return append(notifications, gs.broadcast(func(language string) string {
+			return gs.edited(language, "", text)
+		})...)
+	}
+
+	// In the group chat the host reads the answers with everyone else.
+	if gs.ChatId == 0 {
+		notifications = append(notifications, notify(gs.Host.User, gs.edited(gs.Host.User.LanguageCode, player.NickName, text)))
+	}
+
+	return append(notifications, gs.broadcast(func(language string) string {
+		return gs.edited(language, player.NickName, text)
+	})...)
+}
+
+// edited returns the new version of the message marked as edited,
+// the name is empty for the messages of the host.
+func (gs *GameState) edited(language string, name string, text string) string {
+	if name == "" {
+		name = gs.local.Get(language, "host")
+	}
+
+	return gs.local.Format(language, "EditedMessage", lcl.Params{"name": name, "text": text})
+}
+
+// editsChanged lets the creator of the lobby choose the policy
+// for the edited messages, it cannot be changed during the game.
+func (gs *GameState) editsChanged(id int64, policy EditPolicy) []Notification {
+	player := gs.Player(id)
+	if player == nil {
+		return nil
+	}
+
+	if id != gs.HostId {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "NotACreator"))}
+	}
+
+	if gs.HasStarted() || gs.IsGameOver {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "EditsLocked"))}
+	}
+
+	gs.Settings.Edits = policy
+
+	if policy == ForwardEdits {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "EditsForwarded"))}
+	}
+
+	return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "EditsRejected"))}
+}
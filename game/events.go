@@ -0,0 +1,68 @@
+package game
+
+// Event is something a player did that may change
+// the state of the game.
+type Event interface {
+	isEvent()
+}
+
+// JoinEvent - user wants to join the lobby.
+type JoinEvent struct {
+	User *User
+}
+
+// MessageEvent - player has sent a message to the game.
+type MessageEvent struct {
+	UserID    int64
+	MessageID int
+	Text      string // Text is the caption if the message has a file.
+	Media     *Media
+	FromGroup bool // FromGroup is set when the message was sent to the group chat of the game.
+}
+
+// AnswerEvent - host wants to stop the interrogation and make a guess.
+type AnswerEvent struct {
+	UserID int64
+}
+
+// GuessEvent - host has chosen one of the players in the selector.
+type GuessEvent struct {
+	UserID   int64
+	ChosenID int64
+}
+
+// LeaveEvent - player leaves the lobby or the game.
+type LeaveEvent struct {
+	UserID int64
+}
+
+func (JoinEvent) isEvent()    {}
+func (MessageEvent) isEvent() {}
+func (AnswerEvent) isEvent()  {}
+func (GuessEvent) isEvent()   {}
+func (LeaveEvent) isEvent()   {}
+
+// Type Choice is one of the options the host can pick
+// when he makes his final decision.
+type Choice struct {
+	Label  string
+	UserID int64
+}
+
+// Type Notification is a message the game wants
+// to deliver to one of the users or to the group chat.
+// Bot players read nothing but the notifications with prompts.
+type Notification struct {
+	To      *User
+	Chat    int64 // Chat is the id of the group chat the message is sent to instead of the user.
+	Text    string
+	Choices []Choice // Choices is not empty when the user has to pick one of the players.
+	Replace bool     // Replace is set when the message with choices should be replaced by this one.
+	Prompt  *Prompt  // Prompt is set when the bot player has to answer, such notification is not a message.
+	Media   *Media   // Media is the file that is sent with the text as its caption.
+}
+
+// notify creates a notification for the user.
+func notify(to *User, text string) Notification {
+	return Notification{To: to, Text: text}
+}
@@ -1,147 +1,164 @@
-// Package game_state implements structure
-// and logic of the game.
+// Package game implements structure and logic of the game.
+// It knows nothing about the messenger: it receives events
+// from the players and returns notifications that should be
+// delivered to them.
 package game
 
 import (
-	"log"
 	"math/rand"
-	"strconv"
+	"sync"
 	"time"
 
 	lcl "github.com/dzendos/Turing/config/locales"
 	"github.com/goombaio/namegenerator"
-	tb "gopkg.in/telebot.v3"
 )
 
-// printStatistics sends all the information about the game
-// when the game is over.
-func PrintStatistics(bot *tb.Bot, local *lcl.Localizer, host, knight, knave *Player, state *GameState) {
-	hostResult := local.Get(host.User.LanguageCode, "GameOver")
-	knightResult := local.Get(knight.User.LanguageCode, "GameOver")
-	knaveResult := local.Get(knave.User.LanguageCode, "GameOver")
+// statistics creates all the information about the game
+// that is sent when the game is over.
+func (gs *GameState) statistics() []Notification {
+	var notifications []Notification
 
-	bot.Send(host.User, hostResult)
-	bot.Send(knight.User, knightResult)
-	bot.Send(knave.User, knaveResult)
-
-	begDate := host.State.BegginingDate
+	begDate := gs.BegginingDate
 	gameDuration := time.Since(begDate)
 
-	hostNumberOfMessages := local.Get(host.User.LanguageCode, "NumberOfMessages") + strconv.FormatInt(int64(len(host.History)), 10)
-	hostBegginingDate := local.Get(host.User.LanguageCode, "BegginingDate") + begDate.Format(time.RFC822)
-	hostGameDuration := local.Get(host.User.LanguageCode, "GameDuration") + strconv.FormatInt(int64(gameDuration.Seconds()), 10)
-	hostAnswer := hostNumberOfMessages + "\n" + hostBegginingDate + "\n" + hostGameDuration
-
-	knightNumberOfMessages := local.Get(knight.User.LanguageCode, "NumberOfMessages") + strconv.FormatInt(int64(len(knight.History)), 10)
-	knightBegginingDate := local.Get(knight.User.LanguageCode, "BegginingDate") + begDate.Format(time.RFC822)
-	knightGameDuration := local.Get(knight.User.LanguageCode, "GameDuration") + strconv.FormatInt(int64(gameDuration.Seconds()), 10)
-	knightAnswer := knightNumberOfMessages + "\n" + knightBegginingDate + "\n" + knightGameDuration
-
-	knaveNumberOfMessages := local.Get(knave.User.LanguageCode, "NumberOfMessages") + strconv.FormatInt(int64(len(knave.History)), 10)
-	knaveBegginingDate := local.Get(knave.User.LanguageCode, "BegginingDate") + begDate.Format(time.RFC822)
-	knaveGameDuration := local.Get(knave.User.LanguageCode, "GameDuration") + strconv.FormatInt(int64(gameDuration.Seconds()), 10)
-	knaveAnswer := knaveNumberOfMessages + "\n" + knaveBegginingDate + "\n" + knaveGameDuration
-
-	bot.Send(host.User, hostAnswer)
-	bot.Send(knight.User, knightAnswer)
-	bot.Send(knave.User, knaveAnswer)
-}
-
-type answerHandler struct {
-	Bot   *tb.Bot        // Bot contains reference on a main Bot to be able to send messages throygh it.
-	Local *lcl.Localizer // Local contains dictionary with messages on different languages.
-
-	RightPlayer *Player
+	for _, player := range gs.Players {
+		language := player.User.LanguageCode
 
-	host   *Player
-	knave  *Player
-	knight *Player
-}
+		numberOfMessages := gs.local.Format(language, "NumberOfMessages", lcl.Params{"count": len(player.History)})
+		begginingDate := gs.local.Format(language, "BegginingDate", lcl.Params{"date": begDate.Format(time.RFC822)})
+		duration := gs.local.Plural(language, "GameDuration", int(gameDuration.Seconds()), nil)
 
-func (handler *answerHandler) pressHandle(c tb.Context) error {
-	name := c.Callback().Unique
-
-	if name == handler.RightPlayer.User.FirstName {
-		// Win case.
-		hostAnswer := handler.Local.Get(handler.host.User.LanguageCode, "YouWin")
-		knightAnswer := handler.Local.Get(handler.knight.User.LanguageCode, "YouWin")
-		knaveAnswer := handler.Local.Get(handler.knave.User.LanguageCode, "YouLoose")
-		handler.Bot.Edit(c.Message(), hostAnswer)
-		handler.Bot.Send(handler.knight.User, knightAnswer)
-		handler.Bot.Send(handler.knave.User, knaveAnswer)
-	} else {
-		hostAnswer := handler.Local.Get(handler.host.User.LanguageCode, "YouLoose")
-		knightAnswer := handler.Local.Get(handler.knight.User.LanguageCode, "YouLoose")
-		knaveAnswer := handler.Local.Get(handler.knave.User.LanguageCode, "YouWin")
-		handler.Bot.Edit(c.Message(), hostAnswer)
-		handler.Bot.Send(handler.knight.User, knightAnswer)
-		handler.Bot.Send(handler.knave.User, knaveAnswer)
+		notifications = append(notifications,
+			notify(player.User, gs.local.Get(language, "GameOver")),
+			notify(player.User, numberOfMessages+"\n"+begginingDate+"\n"+duration),
+		)
 	}
 
-	handler.host.State.WasGameFinished = true
-	handler.host.State.WasGameSuccesfull = true
-
-	PrintStatistics(
-		handler.Bot,
-		handler.Local,
-		handler.host,
-		handler.knight,
-		handler.knave,
-		handler.host.State,
-	)
-
-	UploadGame(handler.host, handler.knight, handler.knave)
-
-	return nil
-}
-
-func newAnswerHandler(bot *tb.Bot, local *lcl.Localizer, rightPlayer, host, knave, knight *Player) *answerHandler {
-	return &answerHandler{
-		bot,
-		local,
-		rightPlayer,
-		host,
-		knave,
-		knight,
-	}
+	return notifications
 }
 
 // Type GameState contains all the information about
 // the current game.
 type GameState struct {
+	Id int64 // Id is given to the game by the Registry.
+
 	HasHostFinished   bool
 	HasKnaveFinished  bool
 	HasKnightFinished bool
 	IsHostTurn        bool
 	IsGameRandom      bool
+	IsDecisionTime    bool // IsDecisionTime is set when the host is choosing the player.
+	IsGameOver        bool
+	IsReminded        bool // IsReminded is set when players have been reminded about the deadline.
+
+	Mode GameMode
 
 	NumberOfPlayers int
+	Round           int // Round is the number of the current round, it starts with the host's question.
 
 	WasGameSuccesfull bool
 	WasGameFinished   bool
+	WasGameDecided    bool // WasGameDecided is set when the winner is known: the host has guessed or somebody has not made his turn in time.
+	HostWon           bool
 
 	HostId int64
 
-	BegginingDate time.Time
+	BegginingDate    time.Time
+	GameStartDate    time.Time // GameStartDate is the moment the roles were distributed.
+	TurnStartDate    time.Time
+	LastActivityDate time.Time // LastActivityDate is the moment somebody joined or left the lobby.
 
-	Selector *tb.ReplyMarkup
-	Btn1     tb.Btn
-	Btn2     tb.Btn
+	Settings Settings
 
-	AnswerHandler *answerHandler
-}
+	JoinCode        string // JoinCode is the code others can join the lobby with.
+	JoinCodeExpires time.Time
+	WatchToken      string // WatchToken is the code others can watch the game with, it cannot be guessed like the id of the game.
 
-func (gs *GameState) randomDistribution(bot *tb.Bot, local *lcl.Localizer, currentPlayers *map[int64]*Player) (*Player, *Player, *Player) {
-	var players []*Player
+	ChatId       int64  // ChatId is the group chat the game is played in, 0 if it is played in private chats.
+	ChatLanguage string // ChatLanguage is the language the group chat is told about the game in.
 
-	for _, player := range *currentPlayers {
-		if player.State == gs {
-			players = append(players, player)
+	Spectators          []*User // Spectators follow the game, but cannot write into it.
+	SpectatorsForbidden bool    // SpectatorsForbidden is set when the creator does not want anybody to watch the game.
+
+	Players []*Player // Players contains everyone who is in the lobby or in the game.
+
+	Host        *Player
+	Knave       *Player
+	Knight      *Player
+	RightPlayer *Player // RightPlayer is the player whose real name the host has to guess.
+
+	local *lcl.Localizer
+	mu    sync.Mutex // mu protects the game from players acting at the same time.
+}
+
+// Player returns the player of this game with the given id
+// or nil if there is no such player.
+func (gs *GameState) Player(id int64) *Player {
+	for _, player := range gs.Players {
+		if player.User.ID == id {
+			return player
 		}
 	}
 
+	return nil
+}
+
+// HasStarted checks if the roles have already been distributed.
+func (gs *GameState) HasStarted() bool {
+	return gs.Host != nil
+}
+
+// Handle applies the event to the game and returns
+// everything players should be told about it.
+// Handle is not safe for concurrent use, games that
+// are played through the bot go through Registry.Dispatch.
+func (gs *GameState) Handle(event Event) []Notification {
+	switch e := event.(type) {
+	case JoinEvent:
+		return gs.playerJoined(e.User)
+	case MessageEvent:
+		return gs.messageSent(e.UserID, e.MessageID, e.Text, e.Media, e.FromGroup)
+	case EditEvent:
+		return gs.messageEdited(e.UserID, e.MessageID, e.Text)
+	case EditsEvent:
+		return gs.editsChanged(e.UserID, e.Policy)
+	case AnswerEvent:
+		return gs.answerRequested(e.UserID)
+	case GuessEvent:
+		return gs.guessMade(e.UserID, e.ChosenID)
+	case LeaveEvent:
+		return gs.playerLeft(e.UserID)
+	case TickEvent:
+		return gs.tick(e.Now)
+	case WatchEvent:
+		return gs.spectatorJoined(e.User)
+	case UnwatchEvent:
+		return gs.spectatorLeft(e.UserID)
+	case SpectatorsEvent:
+		return gs.spectatorsChanged(e.UserID, e.Allowed)
+	case AddBotEvent:
+		return gs.botAdded(e.UserID)
+	case LanguageEvent:
+		return gs.languageChanged(e.UserID, e.Language)
+	}
+
+	return nil
+}
+
+func (gs *GameState) randomDistribution() (*Player, *Player, *Player) {
+	players := make([]*Player, len(gs.Players))
+	copy(players, gs.Players)
+
 	shufflePlayers(players)
 
+	// Only a human can be the host.
+	for i, player := range players {
+		if !player.User.IsBot {
+			players[0], players[i] = players[i], players[0]
+			break
+		}
+	}
+
 	players[0].Role = Host
 	players[1].Role = Knave
 	players[2].Role = Knight
@@ -149,20 +166,13 @@ func (gs *GameState) randomDistribution(bot *tb.Bot, local *lcl.Localizer, curre
 	return players[0], players[1], players[2]
 }
 
-func (gs *GameState) creatorIsAHost(bot *tb.Bot, local *lcl.Localizer, currentPlayers *map[int64]*Player) (*Player, *Player, *Player) {
-	var host *Player
-
-	for _, player := range *currentPlayers {
-		if player.State == gs && player.User.ID == gs.HostId {
-			host = player
-			break
-		}
-	}
+func (gs *GameState) creatorIsAHost() (*Player, *Player, *Player) {
+	host := gs.Player(gs.HostId)
 
 	var players []*Player
 
-	for _, player := range *currentPlayers {
-		if player.State == gs && player != host {
+	for _, player := range gs.Players {
+		if player != host {
 			players = append(players, player)
 		}
 	}
@@ -174,161 +184,427 @@ func (gs *GameState) creatorIsAHost(bot *tb.Bot, local *lcl.Localizer, currentPl
 	return host, players[0], players[1]
 }
 
-// PlayerJoined changes the state of the current game
+// playerJoined changes the state of the current game
 // (increases the number of players in the game and
 // if all the players have already connected -> starts the game)
-func (gs *GameState) PlayerJoined(bot *tb.Bot, local *lcl.Localizer, currentPlayers *map[int64]*Player) {
+func (gs *GameState) playerJoined(user *User) []Notification {
+	if gs.Player(user.ID) != nil {
+		return []Notification{notify(user, gs.local.Get(user.LanguageCode, "JoiningYourOwnGame"))}
+	}
+
+	if gs.HasStarted() || gs.IsGameOver {
+		return []Notification{notify(user, gs.local.Get(user.LanguageCode, "UserAlreadyInGame"))}
+	}
+
+	creator := gs.Player(gs.HostId)
+	notifications := []Notification{
+		notify(user, gs.local.Format(user.LanguageCode, "YouJoined", lcl.Params{"name": creator.User.FirstName})),
+	}
+
+	for _, player := range gs.Players {
+		answer := gs.local.Format(player.User.LanguageCode, "SomePlayerJoinedYou", lcl.Params{"name": user.FirstName})
+		notifications = append(notifications, notify(player.User, answer))
+	}
+
+	notifications = append(notifications, gs.broadcast(func(language string) string {
+		return gs.local.Format(language, "SomePlayerJoinedGroupGame", lcl.Params{"name": user.FirstName})
+	})...)
+
+	// The spectator who joins the game sees it as a player.
+	gs.removeSpectator(user.ID)
+
+	gs.Players = append(gs.Players, NewPlayer(user, gs))
 	gs.NumberOfPlayers++
+	gs.LastActivityDate = time.Now()
+	gs.IsReminded = false
 
 	if gs.NumberOfPlayers != 3 {
-		return
+		return notifications
 	}
 
-	// Then we need to change state of people to DistributingRoles state.
+	return append(notifications, gs.start()...)
+}
+
+// start distributes roles between the players and
+// gives the first turn to the host.
+func (gs *GameState) start() []Notification {
 	var host, knight, knave *Player
-	if gs.IsGameRandom {
-		host, knave, knight = gs.randomDistribution(bot, local, currentPlayers)
-	} else {
-		host, knave, knight = gs.creatorIsAHost(bot, local, currentPlayers)
+	switch {
+	case gs.Mode == MachineMode:
+		host, knave, knight = gs.machineRoles()
+	case gs.IsGameRandom:
+		host, knave, knight = gs.randomDistribution()
+	default:
+		host, knave, knight = gs.creatorIsAHost()
 	}
 
+	gs.Host, gs.Knave, gs.Knight = host, knave, knight
+
 	knave.NickName = getRandomNickName()
 	time.Sleep(8 * time.Millisecond)
 	knight.NickName = getRandomNickName()
 
-	// Sending messages
-	hostAnswer := local.Get(host.User.LanguageCode, "HostGreetingMessage") + "\n" +
-		knave.User.FirstName + "\n" +
-		knight.User.FirstName
-
-	KnaveAnswer := local.Get(knave.User.LanguageCode, "KnaveGreetingMessage") + knight.User.FirstName
-	KnightAnswer := local.Get(knight.User.LanguageCode, "KnightGreetingMessage") + knave.User.FirstName
+	hostAnswer := gs.local.Format(host.User.LanguageCode, "HostGreetingMessage",
+		lcl.Params{"first": knave.User.FirstName, "second": knight.User.FirstName})
 
-	bot.Send(host.User, hostAnswer)
-	bot.Send(knave.User, KnaveAnswer)
-	bot.Send(knight.User, KnightAnswer)
+	knaveAnswer := gs.local.Format(knave.User.LanguageCode, "KnaveGreetingMessage", lcl.Params{"name": knight.User.FirstName})
+	knightAnswer := gs.local.Format(knight.User.LanguageCode, "KnightGreetingMessage", lcl.Params{"name": knave.User.FirstName})
 
 	gs.IsHostTurn = true
+	gs.GameStartDate = time.Now()
+	gs.nextTurn()
 
-	// Creating buttons for a host.
+	// Choosing the player the host will have to recognize.
 	rand.Seed(time.Now().UnixNano())
 	randomPlayer := rand.Intn(2)
-	log.Print(randomPlayer)
-
-	var players [2]*Player
-	players[0] = knight
-	players[1] = knave
-
-	// TODO: review
-	gs.Btn1 = gs.Selector.Data(knave.User.FirstName, knave.User.FirstName)
-	gs.Btn2 = gs.Selector.Data(knight.User.FirstName, knight.User.FirstName)
-
-	gs.AnswerHandler = newAnswerHandler(
-		bot,
-		local,
-		players[randomPlayer],
-		host,
-		knave,
-		knight,
-	)
-
-	gs.Selector.Inline(
-		gs.Selector.Row(gs.Btn1, gs.Btn2),
-	)
-
-	bot.Handle(&gs.Btn1, gs.AnswerHandler.pressHandle)
-	bot.Handle(&gs.Btn2, gs.AnswerHandler.pressHandle)
+
+	players := [2]*Player{knight, knave}
+	gs.RightPlayer = players[randomPlayer]
+
+	// In the MachineMode the host is looking for the machine.
+	if gs.Mode == MachineMode {
+		gs.RightPlayer = knave
+	}
+
+	gs.Round = 1
+
+	notifications := []Notification{
+		notify(host.User, hostAnswer),
+		notify(knave.User, knaveAnswer),
+		notify(knight.User, knightAnswer),
+	}
+
+	if gs.Mode == MachineMode {
+		notifications = gs.machineGreetings()
+	}
+
+	notifications = append(notifications, gs.broadcast(func(language string) string {
+		return gs.local.Format(language, "GroupGameStarted", lcl.Params{"name": host.User.FirstName})
+	})...)
+	notifications = append(notifications, gs.watchInvitation()...)
+
+	return append(notifications, gs.roundStarted()...)
 }
 
-// Perform action checks if player can do some action on the current
-// state of the game, and if yes - changes the state of the game.
-func (gs *GameState) PerformAction(player *Player, message *string, bot *tb.Bot, local *lcl.Localizer,
-	currentPlayers *map[int64]*Player) {
+// roundStarted tells everyone the number of the round.
+func (gs *GameState) roundStarted() []Notification {
+	round := func(language string) string {
+		if gs.Settings.Rounds > 0 {
+			return gs.local.Format(language, "RoundOf", lcl.Params{"round": gs.Round, "rounds": gs.Settings.Rounds})
+		}
+
+		return gs.local.Format(language, "Round", lcl.Params{"round": gs.Round})
+	}
+
+	var notifications []Notification
+	for _, player := range gs.Players {
+		notifications = append(notifications, notify(player.User, round(player.User.LanguageCode)))
+	}
 
+	return append(notifications, gs.broadcast(round)...)
+}
+
+// messageSent handles the message written by the player
+// in the lobby or during the game.
+func (gs *GameState) messageSent(id int64, messageId int, message string, media *Media, fromGroup bool) []Notification {
+	player := gs.Player(id)
+	if player == nil {
+		return nil
+	}
+
+	// Only the host talks in the group chat, others would reveal
+	// themselves if they answered there.
+	if fromGroup && player.Role != Host {
+		if player.Role == Lobby {
+			return nil
+		}
+
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "AnswerPrivately"))}
+	}
+
+	if player.Role == Lobby {
+		answer := gs.local.Format(player.User.LanguageCode, "WaitingForOthers", lcl.Params{"count": gs.NumberOfPlayers})
+		return []Notification{notify(player.User, answer)}
+	}
+
+	if media != nil && !gs.isMediaAllowed(media.Type) {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "MediaNotAllowed"))}
+	}
+
+	return gs.performAction(player, messageId, message, media, fromGroup)
+}
+
+// performAction checks if player can do some action on the current
+// state of the game, and if yes - changes the state of the game.
+// The file attached to the message is relayed with it, the id of the message
+// is remembered, so the player can edit it later. The message written
+// in the group chat is not sent back there.
+func (gs *GameState) performAction(player *Player, messageId int, message string, media *Media, fromGroup bool) []Notification {
 	if !player.CanPerformAction() {
-		answer := local.Get(player.User.LanguageCode, "NotYourTurn")
-		bot.Send(player.User, answer)
-		return
+		answer := gs.local.Get(player.User.LanguageCode, "NotYourTurn")
+		return []Notification{notify(player.User, answer)}
 	}
 
-	var host, knight, knave *Player
+	var notifications []Notification
+	host, knight, knave := gs.Host, gs.Knight, gs.Knave
 
-	for _, playerF := range *currentPlayers {
-		if playerF.Role == Host {
-			host = playerF
+	if player.Role == Host {
+		toKnave := gs.local.Get(knave.User.LanguageCode, "host") + ":\n" + message
+		toKnight := gs.local.Get(knight.User.LanguageCode, "host") + ":\n" + message
+
+		gs.HasHostFinished = true
+		gs.HasKnaveFinished = false
+		gs.HasKnightFinished = false
+		gs.IsHostTurn = false
+		gs.nextTurn()
+
+		notifications = append(notifications,
+			Notification{To: knave.User, Text: toKnave, Media: media},
+			Notification{To: knight.User, Text: toKnight, Media: media},
+			notify(knave.User, gs.local.Get(knave.User.LanguageCode, "YourTurn")),
+			notify(knight.User, gs.local.Get(knight.User.LanguageCode, "YourTurn")),
+		)
+
+		audience := gs.broadcast
+		if fromGroup {
+			audience = gs.spectate
 		}
 
-		if playerF.Role == Knight {
-			knight = playerF
+		notifications = append(notifications, withMedia(audience(func(language string) string {
+			return gs.local.Get(language, "host") + ":\n" + message
+		}), media)...)
+	} else {
+		if player == knight {
+			gs.HasKnightFinished = true
+		}
+		if player == knave {
+			gs.HasKnaveFinished = true
 		}
 
-		if playerF.Role == Knave {
-			knave = playerF
+		// In the group chat the host reads the answers with everyone else.
+		answer := player.NickName + ":\n" + message
+		if gs.ChatId == 0 {
+			notifications = append(notifications, Notification{To: host.User, Text: answer, Media: media})
 		}
+		notifications = append(notifications, withMedia(gs.broadcast(func(string) string { return answer }), media)...)
+
+		if gs.HasKnightFinished && gs.HasKnaveFinished {
+			// After the last round the host has to make his choice.
+			if gs.Settings.Rounds > 0 && gs.Round >= gs.Settings.Rounds {
+				notifications = append(notifications, gs.decision()...)
+			} else {
+				gs.HasHostFinished = false
+				gs.IsHostTurn = true
+				gs.Round++
+				gs.nextTurn()
+
+				notifications = append(notifications, gs.roundStarted()...)
+				notifications = append(notifications, notify(host.User, gs.local.Get(host.User.LanguageCode, "YourTurn")))
+			}
+		}
+	}
+
+	history := MessageHistory{
+		Message:        message,
+		TimeFromTheBeg: uint64(time.Since(gs.BegginingDate).Seconds()),
+		MessageID:      messageId,
+	}
+
+	if media != nil {
+		history.MediaType = media.Type
+		history.FileID = media.FileID
 	}
 
+	player.History = append(player.History, history)
+
+	// Bot players answer the question that is already in the history.
 	if player.Role == Host {
-		toKnave := local.Get(knave.User.LanguageCode, "host") + ":\n" + *message
-		toKnight := local.Get(knight.User.LanguageCode, "host") + ":\n" + *message
-		bot.Send(knave.User, toKnave)
-		bot.Send(knight.User, toKnight)
-
-		player.State.HasHostFinished = true
-		player.State.HasKnaveFinished = false
-		player.State.HasKnightFinished = false
-		player.State.IsHostTurn = false
-
-		toKnave = local.Get(knave.User.LanguageCode, "YourTurn")
-		toKnight = local.Get(knight.User.LanguageCode, "YourTurn")
-		bot.Send(knave.User, toKnave)
-		bot.Send(knight.User, toKnight)
+		notifications = append(notifications, gs.prompts()...)
+	}
+
+	return notifications
+}
+
+// answerRequested sends the host a selector with 2 choices - names of the players,
+// so the host can make a decision about the personality and finish the game.
+func (gs *GameState) answerRequested(id int64) []Notification {
+	player := gs.Player(id)
+	if player == nil {
+		return nil
+	}
+
+	if player.Role != Host {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "NotAHostAnswer"))}
+	}
+
+	if gs.IsDecisionTime || gs.IsGameOver {
+		return nil
+	}
+
+	return gs.decision()
+}
+
+// decision stops the interrogation and gives the host the selector.
+func (gs *GameState) decision() []Notification {
+	gs.IsDecisionTime = true
+	gs.nextTurn()
+
+	host, knight, knave := gs.Host, gs.Knight, gs.Knave
+
+	hostAnswer := Notification{
+		To:   host.User,
+		Text: gs.local.Format(host.User.LanguageCode, "WhoIsKnave", lcl.Params{"name": gs.RightPlayer.NickName}),
+		Choices: []Choice{
+			{knave.User.FirstName, knave.User.ID},
+			{knight.User.FirstName, knight.User.ID},
+		},
+	}
+
+	if gs.Mode == MachineMode {
+		hostAnswer.Text = gs.local.Get(host.User.LanguageCode, "WhichIsMachine")
+		hostAnswer.Choices = gs.machineChoices()
+	}
+
+	notifications := []Notification{
+		hostAnswer,
+		notify(knight.User, gs.local.Get(knight.User.LanguageCode, "HostMakingDecision")),
+		notify(knave.User, gs.local.Get(knave.User.LanguageCode, "HostMakingDecision")),
+	}
+
+	return append(notifications, gs.broadcastKey(lcl.Key("HostMakingDecision"))...)
+}
+
+// guessMade finishes the game when the host has chosen the player.
+func (gs *GameState) guessMade(id int64, chosenId int64) []Notification {
+	if !gs.IsDecisionTime || gs.IsGameOver || gs.Host.User.ID != id {
+		return nil
+	}
+
+	gs.HostWon = chosenId == gs.RightPlayer.User.ID
+
+	gs.IsDecisionTime = false
+	gs.IsGameOver = true
+	gs.WasGameFinished = true
+	gs.WasGameDecided = true
+	gs.WasGameSuccesfull = true
+
+	return append(gs.results(true), gs.statistics()...)
+}
+
+// results tells every player if he has won. The host and the knight
+// win together, the knave wins alone. In the MachineMode the human
+// wins with the host and the machine wins alone. If replace is set the message
+// for the host replaces his selector.
+func (gs *GameState) results(replace bool) []Notification {
+	host, knight, knave := gs.Host, gs.Knight, gs.Knave
+
+	var hostAnswer, knightAnswer, knaveAnswer string
+	if gs.HostWon {
+		hostAnswer = gs.local.Get(host.User.LanguageCode, "YouWin")
+		knightAnswer = gs.local.Get(knight.User.LanguageCode, "YouWin")
+		knaveAnswer = gs.local.Get(knave.User.LanguageCode, "YouLoose")
 	} else {
-		if player.Role == Knight {
-			player.State.HasKnightFinished = true
-			playerMessage := knight.NickName + ":\n" + *message
-			bot.Send(host.User, playerMessage)
+		hostAnswer = gs.local.Get(host.User.LanguageCode, "YouLoose")
+		knightAnswer = gs.local.Get(knight.User.LanguageCode, "YouLoose")
+		knaveAnswer = gs.local.Get(knave.User.LanguageCode, "YouWin")
+	}
+
+	notifications := []Notification{
+		{To: host.User, Text: hostAnswer, Replace: replace},
+		notify(knight.User, knightAnswer),
+		notify(knave.User, knaveAnswer),
+	}
+
+	switch {
+	case gs.Mode == MachineMode && gs.HostWon:
+		notifications = append(notifications, gs.broadcastKey(lcl.Key("HostFoundMachine"))...)
+	case gs.Mode == MachineMode:
+		notifications = append(notifications, gs.broadcastKey(lcl.Key("MachineFooledHost"))...)
+	case gs.HostWon:
+		notifications = append(notifications, gs.broadcastKey(lcl.Key("HostWon"))...)
+	default:
+		notifications = append(notifications, gs.broadcastKey(lcl.Key("HostLost"))...)
+	}
+
+	return append(notifications, gs.reveal()...)
+}
+
+// playerLeft deletes player from lobby if the game have not started yet
+// and finishes the game if it has started.
+func (gs *GameState) playerLeft(id int64) []Notification {
+	player := gs.Player(id)
+	if player == nil || gs.IsGameOver {
+		return nil
+	}
+
+	gs.NumberOfPlayers--
+	gs.LastActivityDate = time.Now()
+
+	// Telling others that someone left the lobby.
+	var notifications []Notification
+	for _, playerF := range gs.Players {
+		if playerF != player {
+			answer := gs.local.Format(playerF.User.LanguageCode, "LeftTheLobby", lcl.Params{"name": player.User.FirstName})
+			notifications = append(notifications, notify(playerF.User, answer))
 		}
-		if player.Role == Knave {
-			player.State.HasKnaveFinished = true
-			playerMessage := knave.NickName + ":\n" + *message
-			bot.Send(host.User, playerMessage)
+	}
+
+	notifications = append(notifications, gs.broadcast(func(language string) string {
+		return gs.local.Format(language, "LeftTheLobby", lcl.Params{"name": player.User.FirstName})
+	})...)
+
+	// Nobody wins the game somebody has left, otherwise
+	// the player who is losing could leave to avoid it.
+	if gs.HasStarted() {
+		gs.IsGameOver = true
+		gs.WasGameFinished = true
+
+		notifications = append(notifications, gs.reveal()...)
+		return append(notifications, gs.statistics()...)
+	}
+
+	for i, playerF := range gs.Players {
+		if playerF == player {
+			gs.Players = append(gs.Players[:i], gs.Players[i+1:]...)
+			break
 		}
+	}
 
-		if player.State.HasKnightFinished && player.State.HasKnaveFinished {
-			player.State.HasHostFinished = false
-			player.State.IsHostTurn = true
+	// Bots cannot wait for others without humans.
+	if gs.humans() == 0 {
+		gs.IsGameOver = true
+		return notifications
+	}
 
-			toHost := local.Get(host.User.LanguageCode, "YourTurn")
-			bot.Send(host.User, toHost)
+	if gs.HostId == id {
+		for _, playerF := range gs.Players {
+			if !playerF.User.IsBot {
+				gs.HostId = playerF.User.ID
+				break
+			}
 		}
 	}
 
-	player.History = append(player.History, MessageHistory{
-		*message,
-		uint64(time.Since(player.State.BegginingDate).Seconds()),
-	})
+	return notifications
 }
 
-// NewGameState creates new empty game state.
+// NewGameState creates new game state with the only player - its creator.
 // It is performing only when some user creates a game,
 // that is why number of users by default is 1.
-func NewGameState() *GameState {
-	return &GameState{
-		false,
-		false,
-		false,
-		false,
-		false,
-		1,
-		false,
-		false,
-		0,
-		time.Now(),
-		&tb.ReplyMarkup{},
-		tb.Btn{},
-		tb.Btn{},
-		nil,
+func NewGameState(creator *User, local *lcl.Localizer, settings Settings) *GameState {
+	gs := &GameState{
+		NumberOfPlayers:  1,
+		HostId:           creator.ID,
+		BegginingDate:    time.Now(),
+		LastActivityDate: time.Now(),
+		Settings:         settings,
+		WatchToken:       randomCode(watchTokenLength),
+		local:            local,
 	}
+
+	gs.Players = []*Player{NewPlayer(creator, gs)}
+
+	return gs
 }
 
 // shufflePlayers is used to give random roles for players.
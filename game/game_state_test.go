@@ -0,0 +1,290 @@
+package game
+
+import (
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+var (
+	testLocalOnce sync.Once
+	testLocal     *lcl.Localizer
+)
+
+// local returns the locales of the bot, they are loaded once for all the tests.
+func local(t *testing.T) *lcl.Localizer {
+	t.Helper()
+
+	testLocalOnce.Do(func() {
+		// Problems of the locales are checked by 'turing locales check',
+		// the game logs nothing the tests need.
+		log.SetOutput(io.Discard)
+
+		var err error
+		testLocal, err = lcl.NewLocalizer("../config/locales", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	return testLocal
+}
+
+// text returns the message in English.
+func text(t *testing.T, key string) string {
+	return local(t).Get("en", key)
+}
+
+// Users of the tests: the creator of the game and two others.
+func testUsers() (*User, *User, *User) {
+	return &User{ID: 1, FirstName: "Hanna", LanguageCode: "en"},
+		&User{ID: 2, FirstName: "Kevin", LanguageCode: "en"},
+		&User{ID: 3, FirstName: "Nina", LanguageCode: "en"}
+}
+
+// newLobby creates the lobby of the first user that is joined by the others.
+func newLobby(t *testing.T, settings Settings, users ...*User) *GameState {
+	gs := NewGameState(users[0], local(t), settings)
+	for _, user := range users[1:] {
+		gs.Handle(JoinEvent{User: user})
+	}
+
+	return gs
+}
+
+// newGame creates the game that has started with the first user as the host,
+// the second one is the knave and the third one is the knight.
+func newGame(t *testing.T, settings Settings) *GameState {
+	t.Helper()
+
+	host, knave, knight := testUsers()
+
+	gs := newLobby(t, settings, host, knave, knight)
+	if !gs.HasStarted() {
+		t.Fatal("the game has not started with three players")
+	}
+
+	return gs
+}
+
+// received checks if the user is told the text, the text may be a part of the message.
+func received(notifications []Notification, userId int64, text string) bool {
+	for _, notification := range notifications {
+		if notification.To != nil && notification.To.ID == userId && strings.Contains(notification.Text, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// round plays the round: the host asks the question and both players answer.
+func round(gs *GameState, question string) {
+	gs.Handle(MessageEvent{UserID: gs.Host.User.ID, Text: question})
+	gs.Handle(MessageEvent{UserID: gs.Knave.User.ID, Text: "knave: " + question})
+	gs.Handle(MessageEvent{UserID: gs.Knight.User.ID, Text: "knight: " + question})
+}
+
+func TestJoin(t *testing.T) {
+	host, knave, knight := testUsers()
+	stranger := &User{ID: 4, FirstName: "Sam", LanguageCode: "en"}
+
+	tests := []struct {
+		name        string
+		lobby       []*User
+		user        *User
+		wantPlayers int
+		wantStarted bool
+		wantText    string
+	}{
+		{"second player", []*User{host}, knave, 2, false, local(t).Format("en", "YouJoined", lcl.Params{"name": host.FirstName})},
+		{"creator joins his lobby", []*User{host, knave}, host, 2, false, text(t, "JoiningYourOwnGame")},
+		{"third player starts the game", []*User{host, knave}, knight, 3, true, local(t).Format("en", "KnaveGreetingMessage", lcl.Params{"name": knight.FirstName})},
+		{"game has started", []*User{host, knave, knight}, stranger, 3, true, text(t, "UserAlreadyInGame")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gs := newLobby(t, DefaultSettings(), test.lobby...)
+			notifications := gs.Handle(JoinEvent{User: test.user})
+
+			if len(gs.Players) != test.wantPlayers || gs.NumberOfPlayers != test.wantPlayers {
+				t.Errorf("players = %d (%d), want %d", len(gs.Players), gs.NumberOfPlayers, test.wantPlayers)
+			}
+			if gs.HasStarted() != test.wantStarted {
+				t.Errorf("HasStarted() = %t, want %t", gs.HasStarted(), test.wantStarted)
+			}
+
+			// The knave is greeted when the game starts.
+			to := test.user.ID
+			if test.wantStarted && test.user == knight {
+				to = knave.ID
+			}
+			if !received(notifications, to, test.wantText) {
+				t.Errorf("user %d is not told %q: %+v", to, test.wantText, notifications)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	gs := newGame(t, DefaultSettings())
+	host, knave, knight := testUsers()
+
+	if gs.Host.User.ID != host.ID || gs.Knave.User.ID != knave.ID || gs.Knight.User.ID != knight.ID {
+		t.Fatalf("roles: host %d, knave %d, knight %d", gs.Host.User.ID, gs.Knave.User.ID, gs.Knight.User.ID)
+	}
+
+	if !gs.IsHostTurn || gs.Round != 1 {
+		t.Errorf("the game does not start with the question of the host: IsHostTurn %t, Round %d", gs.IsHostTurn, gs.Round)
+	}
+	if gs.RightPlayer != gs.Knave && gs.RightPlayer != gs.Knight {
+		t.Errorf("the host has to guess the name of %v", gs.RightPlayer)
+	}
+	if gs.Knave.NickName == "" || gs.Knight.NickName == "" {
+		t.Errorf("players have no nicknames")
+	}
+}
+
+func TestTurns(t *testing.T) {
+	gs := newGame(t, DefaultSettings())
+	host, knave, knight := gs.Host.User, gs.Knave.User, gs.Knight.User
+
+	steps := []struct {
+		name      string
+		from      *User
+		text      string
+		to        *User
+		wantText  string
+		wantHost  bool // wantHost is set when the host makes the next turn.
+		wantRound int
+	}{
+		{"knave before the question", knave, "hi", knave, text(t, "NotYourTurn"), true, 1},
+		{"question", host, "Who are you?", knave, "Who are you?", false, 1},
+		{"second question", host, "Well?", host, text(t, "NotYourTurn"), false, 1},
+		{"answer of the knave", knave, "Nina", host, gs.Knave.NickName + ":\nNina", false, 1},
+		{"second answer of the knave", knave, "Really", knave, text(t, "NotYourTurn"), false, 1},
+		{"answer of the knight", knight, "Nina", host, gs.Knight.NickName + ":\nNina", true, 2},
+		{"next question", host, "Sure?", knight, "Sure?", false, 2},
+	}
+
+	for _, step := range steps {
+		notifications := gs.Handle(MessageEvent{UserID: step.from.ID, Text: step.text})
+
+		if !received(notifications, step.to.ID, step.wantText) {
+			t.Errorf("%s: user %d is not told %q", step.name, step.to.ID, step.wantText)
+		}
+		if gs.IsHostTurn != step.wantHost || gs.Round != step.wantRound {
+			t.Errorf("%s: IsHostTurn %t, Round %d, want %t, %d", step.name, gs.IsHostTurn, gs.Round, step.wantHost, step.wantRound)
+		}
+	}
+
+	if len(gs.Host.History) != 2 || len(gs.Knave.History) != 1 || len(gs.Knight.History) != 1 {
+		t.Errorf("histories: %d, %d, %d", len(gs.Host.History), len(gs.Knave.History), len(gs.Knight.History))
+	}
+}
+
+func TestGuess(t *testing.T) {
+	tests := []struct {
+		name        string
+		rounds      int   // rounds is the limit of the rounds in the settings.
+		ask         bool  // ask is set when the host asks for the selector.
+		guesser     int64 // guesser is 0 for the host.
+		right       bool
+		wantOver    bool
+		wantHostWon bool
+	}{
+		{"right guess", 0, true, 0, true, true, true},
+		{"wrong guess", 0, true, 0, false, true, false},
+		{"guess without the selector", 0, false, 0, true, false, false},
+		{"guess of the knave", 0, true, 2, true, false, false},
+		{"after the last round", 1, false, 0, true, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := DefaultSettings()
+			settings.Rounds = test.rounds
+
+			gs := newGame(t, settings)
+			round(gs, "Who are you?")
+
+			if test.ask {
+				gs.Handle(AnswerEvent{UserID: gs.Host.User.ID})
+			}
+
+			chosen := gs.RightPlayer
+			if !test.right {
+				chosen = gs.Knave
+				if gs.RightPlayer == gs.Knave {
+					chosen = gs.Knight
+				}
+			}
+
+			guesser := test.guesser
+			if guesser == 0 {
+				guesser = gs.Host.User.ID
+			}
+
+			gs.Handle(GuessEvent{UserID: guesser, ChosenID: chosen.User.ID})
+
+			if gs.IsGameOver != test.wantOver || gs.WasGameDecided != test.wantOver {
+				t.Errorf("IsGameOver %t, WasGameDecided %t, want %t", gs.IsGameOver, gs.WasGameDecided, test.wantOver)
+			}
+			if gs.HostWon != test.wantHostWon {
+				t.Errorf("HostWon %t, want %t", gs.HostWon, test.wantHostWon)
+			}
+		})
+	}
+}
+
+func TestLeave(t *testing.T) {
+	host, knave, knight := testUsers()
+
+	tests := []struct {
+		name        string
+		lobby       []*User
+		leaves      *User
+		wantOver    bool
+		wantPlayers int
+		wantCreator int64
+	}{
+		{"player leaves the lobby", []*User{host, knave}, knave, false, 1, host.ID},
+		{"creator leaves the lobby", []*User{host, knave}, host, false, 1, knave.ID},
+		{"last player leaves the lobby", []*User{host}, host, true, 0, host.ID},
+		{"knave leaves the game", []*User{host, knave, knight}, knave, true, 3, host.ID},
+		{"host leaves the game", []*User{host, knave, knight}, host, true, 3, host.ID},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gs := newLobby(t, DefaultSettings(), test.lobby...)
+			notifications := gs.Handle(LeaveEvent{UserID: test.leaves.ID})
+
+			if gs.IsGameOver != test.wantOver {
+				t.Errorf("IsGameOver %t, want %t", gs.IsGameOver, test.wantOver)
+			}
+			if len(gs.Players) != test.wantPlayers {
+				t.Errorf("players = %d, want %d", len(gs.Players), test.wantPlayers)
+			}
+			if gs.HostId != test.wantCreator {
+				t.Errorf("creator is %d, want %d", gs.HostId, test.wantCreator)
+			}
+
+			// Nobody wins the game somebody has left.
+			if gs.WasGameDecided {
+				t.Errorf("the game somebody has left is decided")
+			}
+
+			for _, player := range gs.Players {
+				if player.User != test.leaves && !received(notifications, player.User.ID, test.leaves.FirstName) {
+					t.Errorf("user %d is not told that %s has left", player.User.ID, test.leaves.FirstName)
+				}
+			}
+		})
+	}
+}
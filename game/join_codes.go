@@ -0,0 +1,103 @@
+package game
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Join codes are short, so they are easy to type, and do not
+// contain symbols that are easy to confuse (like 0 and O).
+const (
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+
+	// Watch tokens are valid until the game is over, so they are longer.
+	watchTokenLength = 10
+)
+
+// Type joinCode connects the code with the lobby it leads to.
+type joinCode struct {
+	GameId  int64
+	Expires time.Time
+}
+
+// newJoinCode gives the lobby a new code instead of the previous one.
+// It has to be called with r.mu locked.
+func (r *Registry) newJoinCode(state *GameState, now time.Time) {
+	delete(r.codes, state.JoinCode)
+
+	code := randomCode(joinCodeLength)
+	for _, exists := r.codes[code]; exists; _, exists = r.codes[code] {
+		code = randomCode(joinCodeLength)
+	}
+
+	state.JoinCode = code
+	state.JoinCodeExpires = now.Add(r.JoinCodeLifetime)
+	r.codes[code] = joinCode{state.Id, state.JoinCodeExpires}
+}
+
+// Invite gives a new join code to the lobby the user is waiting in.
+// It returns false if the user is not in a lobby.
+func (r *Registry) Invite(userId int64, now time.Time) (string, bool) {
+	state := r.Session(userId)
+	if state == nil {
+		return "", false
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.HasStarted() || state.IsGameOver {
+		return "", false
+	}
+
+	r.mu.Lock()
+	r.newJoinCode(state, now)
+	r.mu.Unlock()
+
+	if r.OnChange != nil {
+		r.OnChange(state)
+	}
+
+	return state.JoinCode, true
+}
+
+// ByCode returns the lobby the join code leads to or nil
+// if there is no such code or it has expired.
+func (r *Registry) ByCode(code string, now time.Time) *GameState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	entry, exists := r.codes[code]
+	if !exists {
+		return nil
+	}
+
+	if now.After(entry.Expires) {
+		delete(r.codes, code)
+		return nil
+	}
+
+	return r.sessions[entry.GameId]
+}
+
+// randomCode generates the code of the given length that cannot be guessed.
+func randomCode(length int) string {
+	var code strings.Builder
+
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+
+		code.WriteByte(joinCodeAlphabet[n.Int64()])
+	}
+
+	return code.String()
+}
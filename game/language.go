@@ -0,0 +1,42 @@
+package game
+
+// LanguageEvent - user has chosen another language.
+type LanguageEvent struct {
+	UserID   int64
+	Language string
+}
+
+func (LanguageEvent) isEvent() {}
+
+// languageChanged makes the game speak with the player
+// or the spectator in the language he has chosen.
+func (gs *GameState) languageChanged(id int64, language string) []Notification {
+	if player := gs.Player(id); player != nil {
+		player.User.LanguageCode = language
+	}
+
+	if spectator := gs.Spectator(id); spectator != nil {
+		spectator.LanguageCode = language
+	}
+
+	return nil
+}
+
+// SetLanguage changes the language of the user in the matchmaking
+// queue and in the games he plays or watches.
+func (r *Registry) SetLanguage(userId int64, language string) []Notification {
+	r.mu.Lock()
+	if position := r.position(userId); position != 0 {
+		r.queue[position-1].User.LanguageCode = language
+	}
+	r.mu.Unlock()
+
+	var notifications []Notification
+	for _, state := range []*GameState{r.Session(userId), r.Watching(userId)} {
+		if state != nil {
+			notifications = append(notifications, r.Dispatch(state, LanguageEvent{UserID: userId, Language: language})...)
+		}
+	}
+
+	return notifications
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"sort"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+// Type GameMode defines what the host has to guess.
+type GameMode int
+
+// Modes of the game.
+const (
+	ClassicMode GameMode = iota // ClassicMode - host guesses the real name of one of the players.
+	MachineMode                 // MachineMode - host guesses which of the players is a machine.
+)
+
+// NewMachineGameState creates new game of the MachineMode, the creator
+// is the host and the machine takes its place in the lobby at once,
+// so the game waits only for one more human.
+func NewMachineGameState(creator *User, local *lcl.Localizer, settings Settings) *GameState {
+	gs := NewGameState(creator, local, settings)
+	gs.Mode = MachineMode
+
+	gs.Players = append(gs.Players, NewPlayer(gs.newBot(creator.LanguageCode), gs))
+	gs.NumberOfPlayers++
+
+	return gs
+}
+
+// machineRoles gives the machine and the human their roles. The machine
+// takes the place of the knave, the human takes the place of the knight.
+func (gs *GameState) machineRoles() (*Player, *Player, *Player) {
+	host := gs.Player(gs.HostId)
+
+	var machine, human *Player
+	for _, player := range gs.Players {
+		switch {
+		case player == host:
+		case player.User.IsBot:
+			machine = player
+		default:
+			human = player
+		}
+	}
+
+	host.Role = Host
+	machine.Role = Machine
+	human.Role = Human
+
+	return host, machine, human
+}
+
+// machineGreetings creates the messages the host and the human
+// start the game of the MachineMode with, the machine needs none.
+func (gs *GameState) machineGreetings() []Notification {
+	host, human := gs.Host, gs.Knight
+
+	return []Notification{
+		notify(host.User, gs.local.Get(host.User.LanguageCode, "MachineHostGreetingMessage")),
+		notify(human.User, gs.local.Get(human.User.LanguageCode, "HumanGreetingMessage")),
+	}
+}
+
+// machineChoices are the nicknames of the players in the alphabetical order,
+// so the order does not tell the host which of them is the machine.
+func (gs *GameState) machineChoices() []Choice {
+	choices := []Choice{
+		{gs.Knave.NickName, gs.Knave.User.ID},
+		{gs.Knight.NickName, gs.Knight.User.ID},
+	}
+
+	sort.Slice(choices, func(i, j int) bool {
+		return choices[i].Label < choices[j].Label
+	})
+
+	return choices
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+// Type waiter is the user in the matchmaking queue.
+type waiter struct {
+	User  *User
+	Since time.Time
+}
+
+// Enqueue puts the user in the matchmaking queue and returns his
+// position in it (starting with 1). If the user is already waiting
+// his current position is returned, if he is playing - 0.
+func (r *Registry) Enqueue(user *User, now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isPlaying := r.players[user.ID]; isPlaying {
+		return 0
+	}
+
+	if position := r.position(user.ID); position != 0 {
+		return position
+	}
+
+	r.queue = append(r.queue, waiter{user, now})
+	return len(r.queue)
+}
+
+// Cancel removes the user from the matchmaking queue.
+// It returns false if the user was not waiting.
+func (r *Registry) Cancel(userId int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	position := r.position(userId)
+	if position == 0 {
+		return false
+	}
+
+	r.queue = append(r.queue[:position-1], r.queue[position:]...)
+	return true
+}
+
+// Position returns the position of the user in the matchmaking
+// queue or 0 if he is not waiting.
+func (r *Registry) Position(userId int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.position(userId)
+}
+
+func (r *Registry) position(userId int64) int {
+	for i, w := range r.queue {
+		if w.User.ID == userId {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// Matchmake groups the users waiting in the queue by three and starts
+// games with random roles for them. Users speaking the same language are
+// grouped first, users of different languages are grouped together only
+// when the first one in the queue has waited for MixLanguagesAfter.
+func (r *Registry) Matchmake(now time.Time, local *lcl.Localizer, settings Settings) []Notification {
+	r.mu.Lock()
+
+	var states []*GameState
+	for {
+		group := r.sameLanguageGroup()
+		if group == nil && len(r.queue) >= 3 && now.Sub(r.queue[0].Since) >= r.MixLanguagesAfter {
+			group = []int{0, 1, 2}
+		}
+
+		if group == nil {
+			break
+		}
+
+		users := make([]*User, len(group))
+		for i, position := range group {
+			users[i] = r.queue[position].User
+		}
+
+		// Removing from the end, so positions stay correct.
+		for i := len(group) - 1; i >= 0; i-- {
+			r.queue = append(r.queue[:group[i]], r.queue[group[i]+1:]...)
+		}
+
+		state := NewGameState(users[0], local, settings)
+		state.IsGameRandom = true
+		for _, user := range users[1:] {
+			state.Players = append(state.Players, NewPlayer(user, state))
+			state.NumberOfPlayers++
+		}
+
+		r.lastId++
+		state.Id = r.lastId
+		r.sessions[state.Id] = state
+		for _, user := range users {
+			r.players[user.ID] = state.Id
+		}
+
+		states = append(states, state)
+	}
+
+	r.mu.Unlock()
+
+	var notifications []Notification
+	for _, state := range states {
+		state.mu.Lock()
+
+		for _, player := range state.Players {
+			notifications = append(notifications, notify(player.User, local.Get(player.User.LanguageCode, "MatchFound")))
+		}
+		notifications = append(notifications, state.start()...)
+
+		if r.OnChange != nil {
+			r.OnChange(state)
+		}
+
+		state.mu.Unlock()
+	}
+
+	return notifications
+}
+
+// sameLanguageGroup returns positions of the first three users
+// in the queue that speak the same language.
+func (r *Registry) sameLanguageGroup() []int {
+	languages := make(map[string][]int)
+
+	for i, w := range r.queue {
+		language := w.User.LanguageCode
+
+		languages[language] = append(languages[language], i)
+		if len(languages[language]) == 3 {
+			return languages[language]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package game
+
+// Type MediaType is the kind of the file attached to the message.
+type MediaType string
+
+// Kinds of the files players can send.
+const (
+	Photo     MediaType = "photo"
+	Video     MediaType = "video"
+	VideoNote MediaType = "video_note"
+	Animation MediaType = "animation"
+	Audio     MediaType = "audio"
+	Voice     MediaType = "voice"
+	Document  MediaType = "document"
+	Sticker   MediaType = "sticker"
+)
+
+// Type Media is the file attached to the message,
+// the file itself is kept by the messenger.
+type Media struct {
+	Type   MediaType
+	FileID string
+}
+
+// DefaultAllowedMedia returns the kinds of the files that can be sent in
+// every mode. Stickers and voice are not allowed, because they tell too much
+// about the person, and nothing is allowed with the machine that cannot see files.
+func DefaultAllowedMedia() map[GameMode][]MediaType {
+	return map[GameMode][]MediaType{
+		ClassicMode: {Photo, Video, Animation, Audio, Document},
+		MachineMode: {},
+	}
+}
+
+// isMediaAllowed checks if the kind of the file can be sent in the game.
+func (gs *GameState) isMediaAllowed(mediaType MediaType) bool {
+	for _, allowed := range gs.Settings.AllowedMedia[gs.Mode] {
+		if allowed == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withMedia attaches the file to all the notifications.
+func withMedia(notifications []Notification, media *Media) []Notification {
+	for i := range notifications {
+		notifications[i].Media = media
+	}
+
+	return notifications
+}
+
+// describe returns the text of the message with the kind
+// of the attached file for those who cannot see files.
+func (message MessageHistory) describe() string {
+	if message.MediaType == "" {
+		return message.Message
+	}
+
+	if message.Message == "" {
+		return "[" + string(message.MediaType) + "]"
+	}
+
+	return "[" + string(message.MediaType) + "] " + message.Message
+}
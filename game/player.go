@@ -1,31 +1,46 @@
 package game
 
-import (
-	tb "gopkg.in/telebot.v3"
-)
-
 type MessageHistory struct {
 	Message        string
 	TimeFromTheBeg uint64
+
+	MediaType MediaType // MediaType is empty if there is no file attached to the message.
+	FileID    string    // FileID is the id the messenger keeps the attached file with.
+
+	MessageID int      // MessageID is the id of the message in the messenger, 0 for the messages of bots.
+	Revisions []string // Revisions are the previous versions of the edited message, the oldest first.
+}
+
+// Type User describes a person taking part in the game
+// independently of the messenger the game is played through.
+type User struct {
+	ID           int64
+	FirstName    string
+	LanguageCode string
+	IsBot        bool // IsBot is set for the players whose answers come from a PlayerBackend.
 }
 
 // Type PlayerRole is used to identify
 // the role of the player in the game.
 type PlayerRole int
 
-// In the game we have three roles that are described here.
+// In the game we have three roles that are described here,
+// in the MachineMode the knave and the knight are replaced with
+// the machine and the human.
 const (
 	Lobby             PlayerRole = iota + 1 // Lobby - player created his game and eaits for others to join.
 	DistributingRoles                       // DistributingRoles - state of the player when roles are assigning.
 	Host                                    // Host is the player who asks the question.
 	Knave                                   // Knave is the player who tries to confuse the Host.
 	Knight                                  // Knight is the player who tries to help to the Host.
+	Machine                                 // Machine is the bot player who pretends to be a human in the MachineMode.
+	Human                                   // Human is the player who proves he is not a machine in the MachineMode.
 )
 
 // Type Player struct contains all neccessary information
 // about the player, that is needed during the game.
 type Player struct {
-	User     *tb.User
+	User     *User
 	Role     PlayerRole
 	NickName string
 	State    *GameState
@@ -36,12 +51,16 @@ type Player struct {
 // CanPerformAction checks if the player with his role can
 // perform some action at current state of the game.
 func (player *Player) CanPerformAction() bool {
+	if player.State.IsDecisionTime || player.State.IsGameOver {
+		return false
+	}
+
 	switch player.Role {
 	case Host:
 		return player.State.IsHostTurn && !player.State.HasHostFinished
-	case Knave:
+	case Knave, Machine:
 		return !player.State.IsHostTurn && !player.State.HasKnaveFinished
-	case Knight:
+	case Knight, Human:
 		return !player.State.IsHostTurn && !player.State.HasKnightFinished
 	}
 
@@ -50,10 +69,10 @@ func (player *Player) CanPerformAction() bool {
 
 // NewPlayer creates new player with the role Lobby initially,
 // because we create new player only during we look for a game.
-func NewPlayer(user *tb.User) *Player {
+func NewPlayer(user *User, state *GameState) *Player {
 	return &Player{
 		User:  user,
 		Role:  Lobby,
-		State: NewGameState(),
+		State: state,
 	}
 }
@@ -0,0 +1,335 @@
+package game
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Type Registry keeps all the lobbies and games that are going on
+// and knows which of them every player belongs to.
+// All its methods are safe for concurrent use.
+type Registry struct {
+	OnChange   func(state *GameState) // OnChange is called every time the game that goes on has been changed.
+	OnGameOver func(state *GameState) // OnGameOver is called once for every game that has been over.
+
+	MixLanguagesAfter time.Duration // MixLanguagesAfter is how long a user waits in the queue for players speaking his language.
+	JoinCodeLifetime  time.Duration // JoinCodeLifetime is how long others can join the lobby by its code.
+
+	mu       sync.Mutex
+	lastId   int64
+	sessions map[int64]*GameState // sessions contains all the games. Key is an id of the game.
+	players  map[int64]int64      // players connects id of the player with id of his game.
+	watchers map[int64]int64      // watchers connects id of the spectator with id of the game he watches.
+	queue    []waiter             // queue contains users waiting for a random game.
+	codes    map[string]joinCode  // codes connects join codes with the lobbies.
+}
+
+// NewRegistry creates empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		sessions: make(map[int64]*GameState),
+		players:  make(map[int64]int64),
+		watchers: make(map[int64]int64),
+		codes:    make(map[string]joinCode),
+	}
+}
+
+// Add registers new game if its creator is not playing already
+// and there is no other game in its group chat.
+func (r *Registry) Add(state *GameState) bool {
+	r.mu.Lock()
+
+	if _, isPlaying := r.players[state.HostId]; isPlaying || r.position(state.HostId) != 0 {
+		r.mu.Unlock()
+		return false
+	}
+
+	// Only one game at a time can be played in the group chat.
+	for _, other := range r.sessions {
+		if state.ChatId != 0 && other.ChatId == state.ChatId {
+			r.mu.Unlock()
+			return false
+		}
+	}
+
+	r.lastId++
+	state.Id = r.lastId
+
+	// Bot players have the same ids in different games,
+	// so only humans are looked for by their ids.
+	r.sessions[state.Id] = state
+	for _, player := range state.Players {
+		if !player.User.IsBot {
+			r.players[player.User.ID] = state.Id
+		}
+	}
+
+	r.newJoinCode(state, time.Now())
+
+	r.mu.Unlock()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if r.OnChange != nil {
+		r.OnChange(state)
+	}
+
+	return true
+}
+
+// Restore registers the game that was going on before the bot
+// has been restarted and tells its players that it goes on.
+func (r *Registry) Restore(state *GameState) []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state.Id > r.lastId {
+		r.lastId = state.Id
+	}
+
+	r.sessions[state.Id] = state
+	for _, player := range state.Players {
+		if !player.User.IsBot {
+			r.players[player.User.ID] = state.Id
+		}
+	}
+
+	for _, spectator := range state.Spectators {
+		r.watchers[spectator.ID] = state.Id
+	}
+
+	if state.JoinCode != "" && !state.HasStarted() {
+		r.codes[state.JoinCode] = joinCode{state.Id, state.JoinCodeExpires}
+	}
+
+	return state.resumed()
+}
+
+// Get returns the game with the given id.
+func (r *Registry) Get(id int64) *GameState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sessions[id]
+}
+
+// Session returns the game the user plays in or nil
+// if he is not in a game.
+func (r *Registry) Session(userId int64) *GameState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, isPlaying := r.players[userId]
+	if !isPlaying {
+		return nil
+	}
+
+	return r.sessions[id]
+}
+
+// ByChat returns the game that is played in the group chat
+// or nil if there is no such game.
+func (r *Registry) ByChat(chatId int64) *GameState {
+	if chatId == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, state := range r.sessions {
+		if state.ChatId == chatId {
+			return state
+		}
+	}
+
+	return nil
+}
+
+// ByWatchToken returns the game spectators can follow with the token
+// or nil if there is no such game.
+func (r *Registry) ByWatchToken(token string) *GameState {
+	token = strings.ToUpper(strings.TrimSpace(token))
+	if token == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, state := range r.sessions {
+		if state.WatchToken == token {
+			return state
+		}
+	}
+
+	return nil
+}
+
+// Watching returns the game the user watches or nil
+// if he is not a spectator.
+func (r *Registry) Watching(userId int64) *GameState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, isWatching := r.watchers[userId]
+	if !isWatching {
+		return nil
+	}
+
+	return r.sessions[id]
+}
+
+// IsPlaying checks if the user is in some lobby or game.
+func (r *Registry) IsPlaying(userId int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, isPlaying := r.players[userId]
+	return isPlaying
+}
+
+// Dispatch passes the event to the game and updates
+// the registry according to the changes in the game.
+func (r *Registry) Dispatch(state *GameState, event Event) []Notification {
+	// User can join only one game at a time, so
+	// we reserve him before the game knows about it.
+	if join, isJoin := event.(JoinEvent); isJoin {
+		if !r.reserve(join.User.ID, state.Id) {
+			return nil
+		}
+	}
+
+	// The same is true for the games user watches.
+	if watch, isWatch := event.(WatchEvent); isWatch {
+		if !r.reserveWatcher(watch.User.ID, state.Id) {
+			return nil
+		}
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	// The game could be over while we were waiting for it.
+	if r.Get(state.Id) != state {
+		r.release(state.Id)
+		return nil
+	}
+
+	notifications := state.Handle(event)
+
+	r.mu.Lock()
+
+	for userId, id := range r.players {
+		if id == state.Id && (state.IsGameOver || state.Player(userId) == nil) {
+			delete(r.players, userId)
+		}
+	}
+
+	for userId, id := range r.watchers {
+		if id == state.Id && (state.IsGameOver || state.Spectator(userId) == nil) {
+			delete(r.watchers, userId)
+		}
+	}
+
+	_, wasActive := r.sessions[state.Id]
+	if state.IsGameOver {
+		delete(r.sessions, state.Id)
+	}
+
+	// Nobody can join the game that has started.
+	if state.HasStarted() || state.IsGameOver {
+		delete(r.codes, state.JoinCode)
+	}
+
+	r.mu.Unlock()
+
+	if state.IsGameOver && wasActive && r.OnGameOver != nil {
+		r.OnGameOver(state)
+	}
+
+	// Nothing changes in the game while time goes on
+	// without anybody to be notified.
+	if _, isTick := event.(TickEvent); isTick && len(notifications) == 0 {
+		return notifications
+	}
+
+	if !state.IsGameOver && r.OnChange != nil {
+		r.OnChange(state)
+	}
+
+	return notifications
+}
+
+// reserve connects the user with the game if he is not playing.
+func (r *Registry) reserve(userId int64, id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isPlaying := r.players[userId]; isPlaying || r.position(userId) != 0 {
+		return false
+	}
+
+	if _, exists := r.sessions[id]; !exists {
+		return false
+	}
+
+	r.players[userId] = id
+	return true
+}
+
+// reserveWatcher connects the spectator with the game
+// if he does not watch another one.
+func (r *Registry) reserveWatcher(userId int64, id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isWatching := r.watchers[userId]; isWatching {
+		return false
+	}
+
+	if _, exists := r.sessions[id]; !exists {
+		return false
+	}
+
+	r.watchers[userId] = id
+	return true
+}
+
+// release forgets the players and spectators that are connected
+// with the game that does not exist anymore.
+func (r *Registry) release(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userId, gameId := range r.players {
+		if gameId == id {
+			delete(r.players, userId)
+		}
+	}
+
+	for userId, gameId := range r.watchers {
+		if gameId == id {
+			delete(r.watchers, userId)
+		}
+	}
+}
+
+// Tick lets all the games check their deadlines.
+func (r *Registry) Tick(now time.Time) []Notification {
+	r.mu.Lock()
+	states := make([]*GameState, 0, len(r.sessions))
+	for _, state := range r.sessions {
+		states = append(states, state)
+	}
+	r.mu.Unlock()
+
+	var notifications []Notification
+	for _, state := range states {
+		notifications = append(notifications, r.Dispatch(state, TickEvent{now})...)
+	}
+
+	return notifications
+}
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	host, knave, knight := testUsers()
+
+	r := NewRegistry()
+
+	var changed, over int
+	r.OnChange = func(*GameState) { changed++ }
+	r.OnGameOver = func(*GameState) { over++ }
+
+	gs := NewGameState(host, local(t), DefaultSettings())
+	if !r.Add(gs) {
+		t.Fatal("the game is not added")
+	}
+	if r.Add(NewGameState(host, local(t), DefaultSettings())) {
+		t.Error("the creator can have two games at the same time")
+	}
+
+	steps := []struct {
+		name        string
+		event       Event
+		wantPlaying []int64
+		wantIdle    []int64
+		wantOver    int
+	}{
+		{"knave joins", JoinEvent{knave}, []int64{host.ID, knave.ID}, []int64{knight.ID}, 0},
+		{"knight joins", JoinEvent{knight}, []int64{host.ID, knave.ID, knight.ID}, nil, 0},
+		{"knave joins again", JoinEvent{knave}, []int64{host.ID, knave.ID, knight.ID}, nil, 0},
+		{"question", MessageEvent{UserID: host.ID, Text: "Who are you?"}, []int64{host.ID, knave.ID, knight.ID}, nil, 0},
+		{"knight leaves", LeaveEvent{knight.ID}, nil, []int64{host.ID, knave.ID, knight.ID}, 1},
+		{"tick after the game", TickEvent{time.Now()}, nil, []int64{host.ID, knave.ID, knight.ID}, 1},
+	}
+
+	for _, step := range steps {
+		r.Dispatch(gs, step.event)
+
+		for _, id := range step.wantPlaying {
+			if r.Session(id) != gs || !r.IsPlaying(id) {
+				t.Errorf("%s: user %d does not play the game", step.name, id)
+			}
+		}
+		for _, id := range step.wantIdle {
+			if r.Session(id) != nil || r.IsPlaying(id) {
+				t.Errorf("%s: user %d plays", step.name, id)
+			}
+		}
+		if over != step.wantOver {
+			t.Errorf("%s: OnGameOver is called %d times, want %d", step.name, over, step.wantOver)
+		}
+	}
+
+	if changed == 0 {
+		t.Error("OnChange is never called")
+	}
+	if r.Get(gs.Id) != nil {
+		t.Error("the game that is over is still in the registry")
+	}
+}
+
+func TestRegistryConcurrentGames(t *testing.T) {
+	r := NewRegistry()
+
+	const games = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < games; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			host := &User{ID: int64(10*i + 1), FirstName: "Hanna", LanguageCode: "en"}
+			gs := NewGameState(host, local(t), DefaultSettings())
+			if !r.Add(gs) {
+				t.Errorf("game %d is not added", i)
+				return
+			}
+
+			for id := int64(2); id <= 3; id++ {
+				r.Dispatch(gs, JoinEvent{&User{ID: int64(10*i) + id, FirstName: "Kevin", LanguageCode: "en"}})
+			}
+			r.Dispatch(gs, MessageEvent{UserID: host.ID, Text: "Who are you?"})
+		}(i)
+	}
+	wg.Wait()
+
+	// Every player is found in his own game.
+	for i := 0; i < games; i++ {
+		for id := int64(1); id <= 3; id++ {
+			userId := int64(10*i) + id
+			gs := r.Session(userId)
+			if gs == nil || gs.Host.User.ID != int64(10*i+1) {
+				t.Errorf("user %d is not in the game of user %d", userId, 10*i+1)
+			}
+		}
+	}
+}
+
+func TestRegistryMachineGames(t *testing.T) {
+	first, second, human := testUsers()
+
+	r := NewRegistry()
+
+	games := []*GameState{
+		NewMachineGameState(first, local(t), DefaultSettings()),
+		NewMachineGameState(second, local(t), DefaultSettings()),
+	}
+
+	for i, gs := range games {
+		if !r.Add(gs) {
+			t.Fatalf("machine game %d is not added", i)
+		}
+	}
+
+	// Machines of different games may have the same id, it belongs to none of them.
+	if machine := games[0].Players[1].User; r.IsPlaying(machine.ID) {
+		t.Errorf("the machine %d is looked for by its id", machine.ID)
+	}
+
+	r.Dispatch(games[0], JoinEvent{human})
+	if !games[0].HasStarted() {
+		t.Fatal("the machine game does not start with two humans")
+	}
+
+	for i, gs := range games {
+		if got := r.Get(gs.Id); got != gs {
+			t.Errorf("game %d is replaced with %v", i, got)
+		}
+	}
+	if r.Session(second.ID) != games[1] {
+		t.Errorf("the second game is lost")
+	}
+}
@@ -0,0 +1,35 @@
+package game
+
+import "time"
+
+// MaxRounds is the biggest number of rounds a game can have.
+const MaxRounds = 50
+
+// Type Settings contains the rules of the game that
+// can be changed in the configuration.
+type Settings struct {
+	TurnTimeout  time.Duration // TurnTimeout is how long a player can think about his turn, 0 - forever.
+	GameTimeout  time.Duration // GameTimeout is how long the game can last after it has started, 0 - forever.
+	LobbyTimeout time.Duration // LobbyTimeout is how long the lobby waits for new players, 0 - forever.
+	Reminder     time.Duration // Reminder is how long before the deadline players are reminded about it.
+
+	Rounds        int // Rounds is the number of questions the host can ask, 0 - until he decides to answer.
+	MaxSpectators int // MaxSpectators is how many users can watch the game at the same time, 0 - nobody.
+
+	AllowedMedia map[GameMode][]MediaType // AllowedMedia contains the kinds of the files players can send in every mode.
+	Edits        EditPolicy               // Edits is what happens with the edited messages, the creator of the lobby can change it.
+}
+
+// DefaultSettings returns the rules that are used if
+// nothing else is specified in the configuration.
+func DefaultSettings() Settings {
+	return Settings{
+		TurnTimeout:   10 * time.Minute,
+		GameTimeout:   2 * time.Hour,
+		LobbyTimeout:  30 * time.Minute,
+		Reminder:      2 * time.Minute,
+		Rounds:        0,
+		MaxSpectators: 10,
+		AllowedMedia:  DefaultAllowedMedia(),
+	}
+}
@@ -0,0 +1,191 @@
+package game
+
+import (
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+// Type Snapshot contains everything that is needed to continue
+// the game after the bot has been restarted.
+type Snapshot struct {
+	Id int64
+
+	HasHostFinished   bool
+	HasKnaveFinished  bool
+	HasKnightFinished bool
+	IsHostTurn        bool
+	IsGameRandom      bool
+	IsDecisionTime    bool
+	IsReminded        bool
+
+	Mode GameMode
+
+	NumberOfPlayers  int
+	Round            int
+	HostId           int64
+	BegginingDate    time.Time
+	GameStartDate    time.Time
+	TurnStartDate    time.Time
+	LastActivityDate time.Time
+
+	Settings Settings
+
+	JoinCode        string
+	JoinCodeExpires time.Time
+	WatchToken      string
+
+	ChatId       int64
+	ChatLanguage string
+
+	Spectators          []User
+	SpectatorsForbidden bool
+
+	Players []PlayerSnapshot
+
+	RightPlayerId int64 // RightPlayerId is 0 if the game has not started yet.
+}
+
+// Type PlayerSnapshot contains everything about the player in the Snapshot.
+type PlayerSnapshot struct {
+	User     User
+	Role     PlayerRole
+	NickName string
+	History  []MessageHistory
+}
+
+// Snapshot saves the current state of the game.
+func (gs *GameState) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Id:                  gs.Id,
+		HasHostFinished:     gs.HasHostFinished,
+		HasKnaveFinished:    gs.HasKnaveFinished,
+		HasKnightFinished:   gs.HasKnightFinished,
+		IsHostTurn:          gs.IsHostTurn,
+		IsGameRandom:        gs.IsGameRandom,
+		IsDecisionTime:      gs.IsDecisionTime,
+		IsReminded:          gs.IsReminded,
+		Mode:                gs.Mode,
+		NumberOfPlayers:     gs.NumberOfPlayers,
+		Round:               gs.Round,
+		HostId:              gs.HostId,
+		BegginingDate:       gs.BegginingDate,
+		GameStartDate:       gs.GameStartDate,
+		TurnStartDate:       gs.TurnStartDate,
+		LastActivityDate:    gs.LastActivityDate,
+		Settings:            gs.Settings,
+		JoinCode:            gs.JoinCode,
+		JoinCodeExpires:     gs.JoinCodeExpires,
+		WatchToken:          gs.WatchToken,
+		ChatId:              gs.ChatId,
+		ChatLanguage:        gs.ChatLanguage,
+		SpectatorsForbidden: gs.SpectatorsForbidden,
+	}
+
+	for _, spectator := range gs.Spectators {
+		snapshot.Spectators = append(snapshot.Spectators, *spectator)
+	}
+
+	for _, player := range gs.Players {
+		snapshot.Players = append(snapshot.Players, PlayerSnapshot{
+			User:     *player.User,
+			Role:     player.Role,
+			NickName: player.NickName,
+			History:  player.History,
+		})
+	}
+
+	if gs.RightPlayer != nil {
+		snapshot.RightPlayerId = gs.RightPlayer.User.ID
+	}
+
+	return snapshot
+}
+
+// RestoreGameState creates the game from its snapshot.
+func RestoreGameState(snapshot Snapshot, local *lcl.Localizer) *GameState {
+	gs := &GameState{
+		Id:                  snapshot.Id,
+		HasHostFinished:     snapshot.HasHostFinished,
+		HasKnaveFinished:    snapshot.HasKnaveFinished,
+		HasKnightFinished:   snapshot.HasKnightFinished,
+		IsHostTurn:          snapshot.IsHostTurn,
+		IsGameRandom:        snapshot.IsGameRandom,
+		IsDecisionTime:      snapshot.IsDecisionTime,
+		IsReminded:          snapshot.IsReminded,
+		Mode:                snapshot.Mode,
+		NumberOfPlayers:     snapshot.NumberOfPlayers,
+		Round:               snapshot.Round,
+		HostId:              snapshot.HostId,
+		BegginingDate:       snapshot.BegginingDate,
+		GameStartDate:       snapshot.GameStartDate,
+		TurnStartDate:       snapshot.TurnStartDate,
+		LastActivityDate:    snapshot.LastActivityDate,
+		Settings:            snapshot.Settings,
+		JoinCode:            snapshot.JoinCode,
+		JoinCodeExpires:     snapshot.JoinCodeExpires,
+		WatchToken:          snapshot.WatchToken,
+		ChatId:              snapshot.ChatId,
+		ChatLanguage:        snapshot.ChatLanguage,
+		SpectatorsForbidden: snapshot.SpectatorsForbidden,
+		local:               local,
+	}
+
+	// Snapshots of the games started before the files were allowed have no list of them.
+	if gs.Settings.AllowedMedia == nil {
+		gs.Settings.AllowedMedia = DefaultAllowedMedia()
+	}
+
+	for i := range snapshot.Spectators {
+		gs.Spectators = append(gs.Spectators, &snapshot.Spectators[i])
+	}
+
+	for _, saved := range snapshot.Players {
+		user := saved.User
+
+		player := NewPlayer(&user, gs)
+		player.Role = saved.Role
+		player.NickName = saved.NickName
+		player.History = saved.History
+
+		gs.Players = append(gs.Players, player)
+
+		switch player.Role {
+		case Host:
+			gs.Host = player
+		case Knave, Machine:
+			gs.Knave = player
+		case Knight, Human:
+			gs.Knight = player
+		}
+	}
+
+	gs.RightPlayer = gs.Player(snapshot.RightPlayerId)
+
+	// Nobody could make his turn or join the lobby while the bot
+	// was stopped, so their clocks start again when the game is restored.
+	gs.nextTurn()
+	gs.LastActivityDate = gs.TurnStartDate
+
+	// Games saved before the watch tokens appeared get a new one.
+	if gs.WatchToken == "" {
+		gs.WatchToken = randomCode(watchTokenLength)
+	}
+
+	return gs
+}
+
+// resumed tells every player and spectator that the game goes on.
+func (gs *GameState) resumed() []Notification {
+	var notifications []Notification
+	for _, player := range gs.Players {
+		notifications = append(notifications, notify(player.User, gs.local.Get(player.User.LanguageCode, "GameResumed")))
+	}
+
+	for _, spectator := range gs.Spectators {
+		notifications = append(notifications, notify(spectator, gs.local.Get(spectator.LanguageCode, "GameResumed")))
+	}
+
+	// Bot players could not answer while the bot was stopped.
+	return append(notifications, gs.prompts()...)
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// restore saves the game the way the storage does and restores it.
+func restore(t *testing.T, snapshot Snapshot) *GameState {
+	t.Helper()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var saved Snapshot
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	return RestoreGameState(saved, local(t))
+}
+
+func TestSnapshot(t *testing.T) {
+	gs := newGame(t, DefaultSettings())
+	gs.Id = 7
+	round(gs, "Who are you?")
+	gs.Handle(MessageEvent{UserID: gs.Host.User.ID, Text: "Sure?"})
+
+	restored := restore(t, gs.Snapshot())
+
+	if restored.Id != gs.Id || restored.Round != 2 || restored.IsHostTurn || !restored.HasHostFinished {
+		t.Errorf("state is not restored: Id %d, Round %d, IsHostTurn %t, HasHostFinished %t",
+			restored.Id, restored.Round, restored.IsHostTurn, restored.HasHostFinished)
+	}
+
+	roles := []struct {
+		name           string
+		got, want      *Player
+		wantHistory    int
+		wantNickName   string
+		wantPlayerRole PlayerRole
+	}{
+		{"host", restored.Host, gs.Host, 2, "", Host},
+		{"knave", restored.Knave, gs.Knave, 1, gs.Knave.NickName, Knave},
+		{"knight", restored.Knight, gs.Knight, 1, gs.Knight.NickName, Knight},
+		{"right player", restored.RightPlayer, gs.RightPlayer, len(gs.RightPlayer.History), gs.RightPlayer.NickName, gs.RightPlayer.Role},
+	}
+
+	for _, role := range roles {
+		if role.got == nil || role.got.User.ID != role.want.User.ID {
+			t.Errorf("%s is not restored", role.name)
+			continue
+		}
+
+		if role.got.Role != role.wantPlayerRole || role.got.NickName != role.wantNickName || len(role.got.History) != role.wantHistory {
+			t.Errorf("%s: role %d, nickname %q, history %d", role.name, role.got.Role, role.got.NickName, len(role.got.History))
+		}
+		if role.got.State != restored {
+			t.Errorf("%s does not belong to the restored game", role.name)
+		}
+	}
+
+	// The game goes on after it has been restored.
+	notifications := restored.Handle(MessageEvent{UserID: restored.Knave.User.ID, Text: "Yes"})
+	if !received(notifications, restored.Host.User.ID, restored.Knave.NickName+":\nYes") {
+		t.Errorf("the answer is not sent to the host after restoring: %+v", notifications)
+	}
+}
+
+func TestRestoreClocks(t *testing.T) {
+	downtime := 15 * time.Minute
+
+	tests := []struct {
+		name     string
+		newState func(t *testing.T) *GameState
+	}{
+		{"game", func(t *testing.T) *GameState { return newGame(t, DefaultSettings()) }},
+		{"lobby", func(t *testing.T) *GameState {
+			host, knave, _ := testUsers()
+			return newLobby(t, DefaultSettings(), host, knave)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gs := test.newState(t)
+
+			// The bot has been stopped longer than the deadlines.
+			snapshot := gs.Snapshot()
+			snapshot.TurnStartDate = snapshot.TurnStartDate.Add(-downtime)
+			snapshot.LastActivityDate = snapshot.LastActivityDate.Add(-time.Hour)
+			snapshot.IsReminded = true
+
+			restored := restore(t, snapshot)
+			restored.Handle(TickEvent{time.Now()})
+
+			if restored.IsGameOver {
+				t.Fatalf("the %s is over right after it has been restored", test.name)
+			}
+			if restored.IsReminded {
+				t.Errorf("the clocks are restarted without the reminder")
+			}
+		})
+	}
+}
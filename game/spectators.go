@@ -0,0 +1,120 @@
+package game
+
+import lcl "github.com/dzendos/Turing/config/locales"
+
+// WatchEvent - user wants to follow the game without playing it.
+type WatchEvent struct {
+	User *User
+}
+
+// UnwatchEvent - spectator does not want to follow the game anymore.
+type UnwatchEvent struct {
+	UserID int64
+}
+
+// SpectatorsEvent - creator of the game allows or forbids spectators.
+type SpectatorsEvent struct {
+	UserID  int64
+	Allowed bool
+}
+
+func (WatchEvent) isEvent()      {}
+func (UnwatchEvent) isEvent()    {}
+func (SpectatorsEvent) isEvent() {}
+
+// Spectator returns the spectator of this game with the given id
+// or nil if there is no such spectator.
+func (gs *GameState) Spectator(id int64) *User {
+	for _, spectator := range gs.Spectators {
+		if spectator.ID == id {
+			return spectator
+		}
+	}
+
+	return nil
+}
+
+// spectatorJoined subscribes the user to the feed of the game
+// if the creator allows it and there is a place for him.
+func (gs *GameState) spectatorJoined(user *User) []Notification {
+	language := user.LanguageCode
+
+	switch {
+	case gs.IsGameOver:
+		return []Notification{notify(user, gs.local.Get(language, "NoSuchGame"))}
+	case gs.Player(user.ID) != nil:
+		return []Notification{notify(user, gs.local.Get(language, "JoiningYourOwnGame"))}
+	case gs.Spectator(user.ID) != nil:
+		return []Notification{notify(user, gs.local.Get(language, "AlreadyWatching"))}
+	case gs.SpectatorsForbidden:
+		return []Notification{notify(user, gs.local.Get(language, "SpectatorsForbidden"))}
+	case len(gs.Spectators) >= gs.Settings.MaxSpectators:
+		return []Notification{notify(user, gs.local.Get(language, "TooManySpectators"))}
+	}
+
+	gs.Spectators = append(gs.Spectators, user)
+
+	return []Notification{notify(user, gs.local.Get(language, "WatchingGame"))}
+}
+
+// spectatorLeft unsubscribes the user from the feed of the game.
+func (gs *GameState) spectatorLeft(id int64) []Notification {
+	spectator := gs.Spectator(id)
+	if spectator == nil {
+		return nil
+	}
+
+	gs.removeSpectator(id)
+
+	return []Notification{notify(spectator, gs.local.Get(spectator.LanguageCode, "StoppedWatching"))}
+}
+
+// spectatorsChanged lets the creator of the game decide if anybody
+// can watch it. Forbidding spectators removes the current ones.
+func (gs *GameState) spectatorsChanged(id int64, allowed bool) []Notification {
+	player := gs.Player(id)
+	if player == nil {
+		return nil
+	}
+
+	if id != gs.HostId {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "NotACreator"))}
+	}
+
+	gs.SpectatorsForbidden = !allowed
+
+	if allowed {
+		return []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "SpectatorsAllowed"))}
+	}
+
+	notifications := []Notification{notify(player.User, gs.local.Get(player.User.LanguageCode, "SpectatorsDisallowed"))}
+	for _, spectator := range gs.Spectators {
+		notifications = append(notifications, notify(spectator, gs.local.Get(spectator.LanguageCode, "SpectatorsRemoved")))
+	}
+
+	gs.Spectators = nil
+
+	return notifications
+}
+
+// removeSpectator forgets the spectator with the given id.
+func (gs *GameState) removeSpectator(id int64) {
+	for i, spectator := range gs.Spectators {
+		if spectator.ID == id {
+			gs.Spectators = append(gs.Spectators[:i], gs.Spectators[i+1:]...)
+			return
+		}
+	}
+}
+
+// watchInvitation tells the creator how others can watch the game.
+func (gs *GameState) watchInvitation() []Notification {
+	if gs.SpectatorsForbidden || gs.Settings.MaxSpectators == 0 {
+		return nil
+	}
+
+	creator := gs.Player(gs.HostId)
+	answer := gs.local.Format(creator.User.LanguageCode, "WatchGame", lcl.Params{"token": gs.WatchToken})
+
+	return []Notification{notify(creator.User, answer)}
+}
@@ -0,0 +1,48 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	r := NewRegistry()
+
+	gs := newGame(t, DefaultSettings())
+	if !r.Add(gs) {
+		t.Fatal("the game is not added")
+	}
+
+	spectator := &User{ID: 4, FirstName: "Sam", LanguageCode: "en"}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantFound bool
+	}{
+		{"watch token", gs.WatchToken, true},
+		{"watch token in lower case", " " + strings.ToLower(gs.WatchToken) + " ", true},
+		{"id of the game", strconv.FormatInt(gs.Id, 10), false},
+		{"join code", gs.JoinCode, false},
+		{"nothing", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if found := r.ByWatchToken(test.token); (found == gs) != test.wantFound {
+				t.Errorf("ByWatchToken(%q) = %v, want found: %t", test.token, found, test.wantFound)
+			}
+		})
+	}
+
+	r.Dispatch(gs, WatchEvent{spectator})
+	if r.Watching(spectator.ID) != gs {
+		t.Errorf("the spectator does not watch the game")
+	}
+
+	// The token survives the restart of the bot.
+	if restored := restore(t, gs.Snapshot()); restored.WatchToken != gs.WatchToken {
+		t.Errorf("watch token %q is restored as %q", gs.WatchToken, restored.WatchToken)
+	}
+}
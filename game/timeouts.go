@@ -0,0 +1,150 @@
+package game
+
+import (
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+// TickEvent - time goes on, so some deadline can pass.
+type TickEvent struct {
+	Now time.Time
+}
+
+func (TickEvent) isEvent() {}
+
+// nextTurn starts the countdown for the turn.
+func (gs *GameState) nextTurn() {
+	gs.TurnStartDate = time.Now()
+	gs.IsReminded = false
+}
+
+// idlePlayers returns the players the game is waiting for.
+func (gs *GameState) idlePlayers() []*Player {
+	if gs.IsDecisionTime || gs.IsHostTurn {
+		return []*Player{gs.Host}
+	}
+
+	var idle []*Player
+	if !gs.HasKnaveFinished {
+		idle = append(idle, gs.Knave)
+	}
+	if !gs.HasKnightFinished {
+		idle = append(idle, gs.Knight)
+	}
+
+	return idle
+}
+
+// tick checks all the deadlines of the game.
+func (gs *GameState) tick(now time.Time) []Notification {
+	if gs.IsGameOver {
+		return nil
+	}
+
+	if !gs.HasStarted() {
+		return gs.lobbyTick(now)
+	}
+
+	if gs.Settings.GameTimeout > 0 && now.Sub(gs.GameStartDate) >= gs.Settings.GameTimeout {
+		return gs.abort(lcl.Key("GameTimeIsUp"))
+	}
+
+	if gs.Settings.TurnTimeout == 0 {
+		return nil
+	}
+
+	idle := gs.idlePlayers()
+	left := gs.Settings.TurnTimeout - now.Sub(gs.TurnStartDate)
+
+	if left <= 0 {
+		return gs.forfeit(idle)
+	}
+
+	if gs.IsReminded || left > gs.Settings.Reminder {
+		return nil
+	}
+
+	gs.IsReminded = true
+
+	var notifications []Notification
+	for _, player := range idle {
+		notifications = append(notifications, notify(player.User, gs.local.Get(player.User.LanguageCode, "HurryUp")))
+	}
+
+	return notifications
+}
+
+// lobbyTick closes the lobby nobody has joined for too long.
+func (gs *GameState) lobbyTick(now time.Time) []Notification {
+	if gs.Settings.LobbyTimeout == 0 {
+		return nil
+	}
+
+	left := gs.Settings.LobbyTimeout - now.Sub(gs.LastActivityDate)
+
+	var key string
+	switch {
+	case left <= 0:
+		gs.IsGameOver = true
+		key = lcl.Key("LobbyExpired")
+	case !gs.IsReminded && left <= gs.Settings.Reminder:
+		gs.IsReminded = true
+		key = lcl.Key("LobbyExpiresSoon")
+	default:
+		return nil
+	}
+
+	var notifications []Notification
+	for _, player := range gs.Players {
+		notifications = append(notifications, notify(player.User, gs.local.Get(player.User.LanguageCode, key)))
+	}
+
+	return append(notifications, gs.broadcastKey(key)...)
+}
+
+// forfeit finishes the game when somebody has not made his turn in time.
+// The side of the idle player loses, if both knave and knight are
+// idle there is nobody to win and the game is aborted.
+func (gs *GameState) forfeit(idle []*Player) []Notification {
+	if len(idle) != 1 {
+		return gs.abort(lcl.Key("GameAborted"))
+	}
+
+	gs.IsDecisionTime = false
+	gs.IsGameOver = true
+	gs.WasGameFinished = true
+	gs.WasGameDecided = true
+	gs.HostWon = idle[0] == gs.Knave
+
+	var notifications []Notification
+	for _, player := range gs.Players {
+		answer := gs.local.Format(player.User.LanguageCode, "DidNotAnswerInTime", lcl.Params{"name": idle[0].User.FirstName})
+		notifications = append(notifications, notify(player.User, answer))
+	}
+
+	notifications = append(notifications, gs.broadcast(func(language string) string {
+		return gs.local.Format(language, "DidNotAnswerInTime", lcl.Params{"name": idle[0].User.FirstName})
+	})...)
+
+	notifications = append(notifications, gs.results(false)...)
+
+	return append(notifications, gs.statistics()...)
+}
+
+// abort finishes the game without a winner.
+func (gs *GameState) abort(reason string) []Notification {
+	gs.IsDecisionTime = false
+	gs.IsGameOver = true
+	gs.WasGameFinished = false
+
+	var notifications []Notification
+	for _, player := range gs.Players {
+		notifications = append(notifications, notify(player.User, gs.local.Get(player.User.LanguageCode, reason)))
+	}
+
+	notifications = append(notifications, gs.broadcastKey(reason)...)
+	notifications = append(notifications, gs.reveal()...)
+
+	return append(notifications, gs.statistics()...)
+}
@@ -0,0 +1,132 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTick(t *testing.T) {
+	settings := DefaultSettings()
+
+	tests := []struct {
+		name        string
+		lobby       bool // lobby is set when the game has not started.
+		prepare     func(gs *GameState)
+		after       time.Duration // after is the time from the last turn or activity in the lobby.
+		wantOver    bool
+		wantDecided bool
+		wantHostWon bool
+		wantText    string
+		wantTo      func(gs *GameState) int64
+	}{
+		{
+			name:   "nothing happens",
+			after:  time.Minute,
+			wantTo: func(*GameState) int64 { return 0 },
+		},
+		{
+			name:     "host is reminded",
+			after:    settings.TurnTimeout - settings.Reminder + time.Second,
+			wantText: "HurryUp",
+			wantTo:   func(gs *GameState) int64 { return gs.Host.User.ID },
+		},
+		{
+			name:        "host forfeits",
+			after:       settings.TurnTimeout,
+			wantOver:    true,
+			wantDecided: true,
+			wantHostWon: false,
+			wantText:    "YouWin",
+			wantTo:      func(gs *GameState) int64 { return gs.Knave.User.ID },
+		},
+		{
+			name: "knave forfeits",
+			prepare: func(gs *GameState) {
+				gs.Handle(MessageEvent{UserID: gs.Host.User.ID, Text: "Who are you?"})
+				gs.Handle(MessageEvent{UserID: gs.Knight.User.ID, Text: "Nina"})
+			},
+			after:       settings.TurnTimeout,
+			wantOver:    true,
+			wantDecided: true,
+			wantHostWon: true,
+			wantText:    "YouWin",
+			wantTo:      func(gs *GameState) int64 { return gs.Host.User.ID },
+		},
+		{
+			name: "both players are idle",
+			prepare: func(gs *GameState) {
+				gs.Handle(MessageEvent{UserID: gs.Host.User.ID, Text: "Who are you?"})
+			},
+			after:    settings.TurnTimeout,
+			wantOver: true,
+			wantText: "GameAborted",
+			wantTo:   func(gs *GameState) int64 { return gs.Host.User.ID },
+		},
+		{
+			name: "game is too long",
+			prepare: func(gs *GameState) {
+				gs.GameStartDate = gs.GameStartDate.Add(-settings.GameTimeout)
+			},
+			after:    time.Minute,
+			wantOver: true,
+			wantText: "GameTimeIsUp",
+			wantTo:   func(gs *GameState) int64 { return gs.Knight.User.ID },
+		},
+		{
+			name:     "lobby is reminded",
+			lobby:    true,
+			after:    settings.LobbyTimeout - settings.Reminder + time.Second,
+			wantText: "LobbyExpiresSoon",
+			wantTo:   func(gs *GameState) int64 { return gs.HostId },
+		},
+		{
+			name:     "lobby expires",
+			lobby:    true,
+			after:    settings.LobbyTimeout,
+			wantOver: true,
+			wantText: "LobbyExpired",
+			wantTo:   func(gs *GameState) int64 { return gs.HostId },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gs *GameState
+			if test.lobby {
+				host, knave, _ := testUsers()
+				gs = newLobby(t, settings, host, knave)
+			} else {
+				gs = newGame(t, settings)
+			}
+
+			if test.prepare != nil {
+				test.prepare(gs)
+			}
+
+			last := gs.TurnStartDate
+			if test.lobby {
+				last = gs.LastActivityDate
+			}
+
+			notifications := gs.Handle(TickEvent{last.Add(test.after)})
+
+			if gs.IsGameOver != test.wantOver || gs.WasGameDecided != test.wantDecided {
+				t.Errorf("IsGameOver %t, WasGameDecided %t, want %t, %t", gs.IsGameOver, gs.WasGameDecided, test.wantOver, test.wantDecided)
+			}
+			if test.wantDecided && gs.HostWon != test.wantHostWon {
+				t.Errorf("HostWon %t, want %t", gs.HostWon, test.wantHostWon)
+			}
+
+			if test.wantText == "" {
+				if len(notifications) != 0 {
+					t.Errorf("notifications without a deadline: %+v", notifications)
+				}
+				return
+			}
+
+			if to := test.wantTo(gs); !received(notifications, to, text(t, test.wantText)) {
+				t.Errorf("user %d is not told %q: %+v", to, test.wantText, notifications)
+			}
+		})
+	}
+}
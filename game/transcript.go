@@ -0,0 +1,221 @@
+package game
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+// Type Transcript is the whole dialogue of the game
+// with the real names of the players revealed.
+type Transcript struct {
+	GameId   int64              `json:"game_id"`
+	Mode     string             `json:"mode"`
+	Start    time.Time          `json:"start"`
+	Decided  bool               `json:"decided"` // Decided is not set if the game has been aborted or somebody has left it.
+	HostWon  bool               `json:"host_won"`
+	Players  []TranscriptPlayer `json:"players"`
+	Messages []TranscriptLine   `json:"messages"`
+}
+
+// Type TranscriptPlayer is the participant of the game in the Transcript.
+// The transcript is sent to everyone in the game, so the telegram ids
+// of the players are kept out of its JSON.
+type TranscriptPlayer struct {
+	UserID   int64  `json:"-"`
+	Name     string `json:"name"`
+	NickName string `json:"nickname,omitempty"`
+	Role     string `json:"role"`
+	IsBot    bool   `json:"is_bot,omitempty"`
+}
+
+// Type TranscriptLine is one message of the Transcript.
+type TranscriptLine struct {
+	Round     int       `json:"round"`
+	UserID    int64     `json:"-"`
+	Role      string    `json:"role"` // Role tells who has written the message.
+	Text      string    `json:"text"`
+	Time      uint64    `json:"time"` // Time is the number of seconds from the beginning of the game.
+	MediaType MediaType `json:"media_type,omitempty"`
+	FileID    string    `json:"file_id,omitempty"`
+}
+
+// transcriptRoles are the keys of the names of the roles in the transcript.
+var transcriptRoles = map[string]string{
+	Host.String():    lcl.Key("TranscriptRole_host"),
+	Knave.String():   lcl.Key("TranscriptRole_knave"),
+	Knight.String():  lcl.Key("TranscriptRole_knight"),
+	Machine.String(): lcl.Key("TranscriptRole_machine"),
+	Human.String():   lcl.Key("TranscriptRole_human"),
+}
+
+// String returns the name of the role.
+func (role PlayerRole) String() string {
+	switch role {
+	case Lobby:
+		return "lobby"
+	case DistributingRoles:
+		return "distributing_roles"
+	case Host:
+		return "host"
+	case Knave:
+		return "knave"
+	case Knight:
+		return "knight"
+	case Machine:
+		return "machine"
+	case Human:
+		return "human"
+	}
+
+	return "role_" + strconv.Itoa(int(role))
+}
+
+// String returns the name of the mode.
+func (mode GameMode) String() string {
+	switch mode {
+	case ClassicMode:
+		return "classic"
+	case MachineMode:
+		return "machine"
+	}
+
+	return "mode_" + strconv.Itoa(int(mode))
+}
+
+// NewTranscript interleaves the messages of the players: every round
+// starts with the question of the host that is followed by the answers.
+// Histories contain the messages of every player in the order they
+// were sent, the key is the id of the player.
+func NewTranscript(transcript Transcript, histories map[int64][]MessageHistory) Transcript {
+	transcript.Messages = nil
+
+	for _, player := range transcript.Players {
+		for i, message := range histories[player.UserID] {
+			transcript.Messages = append(transcript.Messages, TranscriptLine{
+				Round:     i + 1,
+				UserID:    player.UserID,
+				Role:      player.Role,
+				Text:      message.Message,
+				Time:      message.TimeFromTheBeg,
+				MediaType: message.MediaType,
+				FileID:    message.FileID,
+			})
+		}
+	}
+
+	sort.SliceStable(transcript.Messages, func(i, j int) bool {
+		first, second := transcript.Messages[i], transcript.Messages[j]
+		if first.Round != second.Round {
+			return first.Round < second.Round
+		}
+		if (first.Role == "host") != (second.Role == "host") {
+			return first.Role == "host"
+		}
+		return first.Time < second.Time
+	})
+
+	return transcript
+}
+
+// Transcript creates the transcript of the game that has started.
+func (gs *GameState) Transcript() Transcript {
+	transcript := Transcript{
+		GameId:  gs.Id,
+		Mode:    gs.Mode.String(),
+		Start:   gs.BegginingDate.UTC(), // Start is in UTC, like in the stored transcripts.
+		Decided: gs.WasGameDecided,
+		HostWon: gs.HostWon,
+	}
+
+	histories := make(map[int64][]MessageHistory)
+	for _, player := range []*Player{gs.Host, gs.Knave, gs.Knight} {
+		transcript.Players = append(transcript.Players, TranscriptPlayer{
+			UserID:   player.User.ID,
+			Name:     player.User.FirstName,
+			NickName: player.NickName,
+			Role:     player.Role.String(),
+			IsBot:    player.User.IsBot,
+		})
+
+		histories[player.User.ID] = player.History
+	}
+
+	return NewTranscript(transcript, histories)
+}
+
+// Player returns the participant of the game with the given id.
+func (transcript Transcript) Player(id int64) (TranscriptPlayer, bool) {
+	for _, player := range transcript.Players {
+		if player.UserID == id {
+			return player, true
+		}
+	}
+
+	return TranscriptPlayer{}, false
+}
+
+// JSON returns the transcript in JSON.
+func (transcript Transcript) JSON() ([]byte, error) {
+	return json.MarshalIndent(transcript, "", "  ")
+}
+
+// Markdown returns the transcript in Markdown in the given language.
+func (transcript Transcript) Markdown(local *lcl.Localizer, language string) string {
+	var text strings.Builder
+
+	text.WriteString("# " + local.Format(language, "TranscriptTitle", lcl.Params{"id": transcript.GameId}) + "\n\n")
+	text.WriteString(local.Format(language, "BegginingDate", lcl.Params{"date": transcript.Start.Format(time.RFC822)}) + "\n\n")
+
+	for _, player := range transcript.Players {
+		role, isKnown := transcriptRoles[player.Role]
+		if !isKnown {
+			role = player.Role
+		}
+
+		text.WriteString("- **" + local.Get(language, role) + "**: " + player.Name)
+		if player.NickName != "" {
+			text.WriteString(" (" + player.NickName + ")")
+		}
+		text.WriteString("\n")
+	}
+	text.WriteString("\n")
+
+	round := 0
+	for _, message := range transcript.Messages {
+		if message.Round != round {
+			round = message.Round
+			text.WriteString("## " + local.Format(language, "Round", lcl.Params{"round": round}) + "\n\n")
+		}
+
+		player, _ := transcript.Player(message.UserID)
+
+		name := player.Name
+		if player.NickName != "" {
+			name = player.NickName + " - " + player.Name
+		}
+
+		text.WriteString("**" + name + "** (" + local.Format(language, "Seconds", lcl.Params{"count": message.Time}) + "): ")
+		text.WriteString(MessageHistory{Message: message.Text, MediaType: message.MediaType}.describe() + "\n\n")
+	}
+
+	switch {
+	case !transcript.Decided:
+		text.WriteString(local.Get(language, "TranscriptAborted"))
+	case transcript.Mode == MachineMode.String() && transcript.HostWon:
+		text.WriteString(local.Get(language, "HostFoundMachine"))
+	case transcript.Mode == MachineMode.String():
+		text.WriteString(local.Get(language, "MachineFooledHost"))
+	case transcript.HostWon:
+		text.WriteString(local.Get(language, "HostWon"))
+	default:
+		text.WriteString(local.Get(language, "HostLost"))
+	}
+	text.WriteString("\n")
+
+	return text.String()
+}
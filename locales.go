@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	lcl "github.com/dzendos/Turing/config/locales"
+)
+
+// runLocales runs 'locales check' that validates the locales and
+// 'locales keys' that generates the list of the keys used in the code.
+func runLocales(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: turing locales check|keys [flags]")
+	}
+
+	switch args[0] {
+	case "check":
+		return checkLocales(args[1:])
+	case "keys":
+		return generateKeys(args[1:])
+	}
+
+	return fmt.Errorf("unknown command locales %s", args[0])
+}
+
+// checkLocales prints everything wrong with the locales. Keys used in the code
+// must be in the reference locale, in the strict mode every problem is an error.
+func checkLocales(args []string) error {
+	flags := flag.NewFlagSet("locales check", flag.ExitOnError)
+	dir := flags.String("dir", lcl.DefaultDir, "directory with the locales")
+	src := flags.String("src", ".", "directory with the code that uses the locales")
+	strict := flags.Bool("strict", false, "fail on missing and extra keys too")
+	flags.Parse(args)
+
+	// The problems are printed below with the keys found in the code,
+	// those logged while loading would be the same.
+	log.SetOutput(io.Discard)
+	local, err := lcl.NewLocalizer(*dir, false)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	keys, err := lcl.ScanKeys(*src)
+	if err != nil {
+		return err
+	}
+
+	report := local.Validate(keys)
+	for _, problem := range report.Problems() {
+		fmt.Println(problem)
+	}
+
+	if len(report.Unknown) > 0 || len(report.Mismatches) > 0 || (*strict && !report.IsEmpty()) {
+		return fmt.Errorf("locales in %s are not valid", *dir)
+	}
+
+	fmt.Printf("%d locales, %d keys used in the code: ok\n", len(local.Languages()), len(keys))
+	return nil
+}
+
+// generateKeys writes the Go file with the keys used in the code,
+// so they can be checked when the bot starts.
+func generateKeys(args []string) error {
+	flags := flag.NewFlagSet("locales keys", flag.ExitOnError)
+	src := flags.String("src", ".", "directory with the code that uses the locales")
+	out := flags.String("out", lcl.DefaultDir+"/keys.go", "file to write the keys to")
+	flags.Parse(args)
+
+	keys, err := lcl.ScanKeys(*src)
+	if err != nil {
+		return err
+	}
+
+	var code bytes.Buffer
+	code.WriteString("// Code generated by \"turing locales keys\"; DO NOT EDIT.\n\n")
+	code.WriteString("package locales\n\n")
+	code.WriteString("// UsedKeys are the keys of the messages used in the code.\n")
+	code.WriteString("var UsedKeys = []string{\n")
+	for _, key := range keys {
+		code.WriteString(strconv.Quote(key) + ",\n")
+	}
+	code.WriteString("}\n")
+
+	formatted, err := format.Source(code.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*out, formatted, 0o644)
+}
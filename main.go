@@ -2,19 +2,60 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/dzendos/Turing/config"
+	db "github.com/dzendos/Turing/database"
 )
 
 func main() {
-	bot, err := config.InitializeBot()
+	// 'locales' subcommand checks the locales without starting the bot.
+	if len(os.Args) > 1 && os.Args[1] == "locales" {
+		if err := runLocales(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// 'migrate' subcommand only brings the database schema up to date,
+	// it needs no settings of the bot.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		database, err := config.LoadDatabase(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		storage, err := db.Open(database)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		storage.Close()
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	storage, err := db.Open(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer storage.Close()
+
+	bot, err := config.InitializeBot(cfg)
 
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 
-	config.InitializeBotHandler(bot)
+	if err := config.InitializeBotHandler(bot, storage, cfg); err != nil {
+		log.Fatal(err)
+	}
 
 	bot.Start()
 }